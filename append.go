@@ -0,0 +1,92 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Appends data to the blob at the given key, creating it if missing, such as
+// to accumulate newline-delimited events. The file is opened in append mode,
+// so concurrent appends do not overwrite each other, although large appends
+// may interleave. With WithChecksums the checksum of the whole blob is
+// recomputed, which reads it again.
+func (l *Fs) Append(ctx context.Context, key string, data []byte) (err error) {
+	defer annotate(&err, "fs", "Append", key)
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, err := l.filePath(key)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("appending: %w", err)
+	}
+	if err := l.syncFile(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing file: %w", err)
+	}
+	if err := l.syncDir(dir); err != nil {
+		return err
+	}
+
+	// The stored checksum covers the previous content only.
+	m, err := l.readMeta(key)
+	if err != nil {
+		return err
+	}
+	m.SHA256 = ""
+	if l.checksums {
+		if m.SHA256, err = fileSHA256(path); err != nil {
+			return err
+		}
+	}
+	return l.writeMeta(key, m)
+}
+
+// Returns the hex encoded SHA256 of a file's content.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("opening file: %w", err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hashing: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Appends data to the blob at the given key, creating it if missing, see
+// AppendViaCompose. Objects are immutable on GCS, so every append uploads
+// data as a temporary object and composes the blob from itself and it, which
+// costs an upload, a compose and a delete operation. A compose takes at most
+// 32 sources and a composite object at most 1024 components, so the blob is
+// flattened by a read and rewrite every 1023 appends. Concurrent appends fail
+// with ErrPreconditionFailed and can be retried.
+func (g *Gcs) Append(ctx context.Context, key string, data []byte) (err error) {
+	defer annotate(&err, "gcs", "Append", key)
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	return g.AppendViaCompose(ctx, key, data)
+}
@@ -0,0 +1,74 @@
+package blob_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestLocalFiles_Append(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_append"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath, blob.WithChecksums(), blob.WithVerifyChecksums())
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		if err := localFS.Append(ctx, "events/2024-01-01.ndjson", []byte(line)); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	// The stored checksum must cover all appends for the verified read to pass.
+	data, err := localFS.Read(ctx, "events/2024-01-01.ndjson")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if string(data) != "first\nsecond\nthird\n" {
+		t.Fatalf("Expected all appended lines, got %q", data)
+	}
+	if corrupted, err := localFS.VerifyAll(ctx, "events/"); err != nil || len(corrupted) != 0 {
+		t.Fatalf("VerifyAll = %v, %v", corrupted, err)
+	}
+}
+
+func TestGcsBucket_AppendViaCompose(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGcs(t, "test-bucket")
+	gcs := fake.storage(t, "logs")
+
+	if err := gcs.AppendViaCompose(ctx, "app.log", []byte("first\n")); err != nil {
+		t.Fatalf("AppendViaCompose of missing blob failed: %v", err)
+	}
+	if err := gcs.UpdateMetadata(ctx, "app.log", map[string]string{"owner": "alice"}, blob.MetadataMerge); err != nil {
+		t.Fatalf("UpdateMetadata failed: %v", err)
+	}
+	if err := gcs.AppendViaCompose(ctx, "app.log", []byte("second\n")); err != nil {
+		t.Fatalf("AppendViaCompose failed: %v", err)
+	}
+	obj := fake.object("logs/app.log")
+	if string(obj.data) != "first\nsecond\n" || obj.componentCount != 2 {
+		t.Fatalf("Expected a composite of both appends, got %q with %d components", obj.data, obj.componentCount)
+	}
+	if obj.metadata["owner"] != "alice" {
+		t.Fatalf("Expected the metadata to be kept, got %v", obj.metadata)
+	}
+	if n := fake.count("POST compose"); n != 1 {
+		t.Fatalf("Expected one compose, got %d", n)
+	}
+	if keys, err := gcs.List(ctx, ""); err != nil || len(keys) != 1 {
+		t.Fatalf("Expected the temporary object to be removed, got %v, %v", keys, err)
+	}
+
+	// A blob at the component limit is flattened before appending.
+	fake.mu.Lock()
+	fake.objects["logs/app.log"].componentCount = 1023
+	fake.mu.Unlock()
+	if err := gcs.AppendViaCompose(ctx, "app.log", []byte("third\n")); err != nil {
+		t.Fatalf("AppendViaCompose at the component limit failed: %v", err)
+	}
+	obj = fake.object("logs/app.log")
+	if string(obj.data) != "first\nsecond\nthird\n" || obj.componentCount != 2 {
+		t.Fatalf("Expected a flattened composite of all appends, got %q with %d components", obj.data, obj.componentCount)
+	}
+}
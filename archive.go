@@ -0,0 +1,190 @@
+package blob
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// Writes all blobs under folder to w as a zip archive, naming each entry by
+// its key relative to folder. Blobs are streamed into the archive one at a
+// time, so the folder is never held in memory. If an error occurs midway, the
+// archive is left unfinished so it cannot be mistaken for a complete one.
+func ZipFolder(ctx context.Context, s Storage, folder string, w io.Writer) error {
+	prefix := strings.TrimSuffix(folder, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	keys, err := s.List(ctx, prefix)
+	if err != nil {
+		return fmt.Errorf("listing folder: %w", err)
+	}
+
+	zw := zip.NewWriter(w)
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := zipBlob(ctx, s, zw, key, strings.TrimPrefix(key, prefix)); err != nil {
+			return fmt.Errorf("adding %s: %w", key, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("closing zip writer: %w", err)
+	}
+	return nil
+}
+
+// Streams a single blob into a new zip entry.
+func zipBlob(ctx context.Context, s Storage, zw *zip.Writer, key, name string) error {
+	rc, err := s.Reader(ctx, key)
+	if err != nil {
+		return fmt.Errorf("opening reader: %w", err)
+	}
+	defer rc.Close()
+
+	entry, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("creating entry: %w", err)
+	}
+	if _, err := io.Copy(entry, rc); err != nil {
+		return fmt.Errorf("copying: %w", err)
+	}
+	return nil
+}
+
+// Format of an archive read by ImportArchive.
+type ArchiveFormat int
+
+const (
+	ArchiveTar ArchiveFormat = iota // Uncompressed tar archive.
+	ArchiveZip                      // Zip archive.
+)
+
+// Writes every file in the archive read from r as a blob under prefix, using
+// its name within the archive as the relative key, and returns the number of
+// blobs written. Entries are streamed one at a time. Directories and other
+// non-regular entries such as symlinks are skipped, and the import fails at
+// the first entry whose name is absolute or escapes prefix with "..".
+//
+// Zip archives need random access, so r is read directly only if it is an
+// io.ReaderAt and io.Seeker such as an *os.File, and otherwise buffered in a
+// temporary file first.
+func ImportArchive(ctx context.Context, s Storage, prefix string, r io.Reader, format ArchiveFormat) (imported int, err error) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	switch format {
+	case ArchiveTar:
+		return importTar(ctx, s, prefix, r)
+	case ArchiveZip:
+		return importZip(ctx, s, prefix, r)
+	default:
+		return 0, fmt.Errorf("unknown archive format %d", format)
+	}
+}
+
+// Imports the regular files of a tar archive.
+func importTar(ctx context.Context, s Storage, prefix string, r io.Reader) (int, error) {
+	tr := tar.NewReader(r)
+	imported := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return imported, nil
+		}
+		if err != nil {
+			return imported, fmt.Errorf("reading tar header: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := importEntry(ctx, s, prefix, hdr.Name, tr); err != nil {
+			return imported, err
+		}
+		imported++
+	}
+}
+
+// Imports the regular files of a zip archive.
+func importZip(ctx context.Context, s Storage, prefix string, r io.Reader) (int, error) {
+	ra, size, cleanup, err := readerAt(r)
+	if err != nil {
+		return 0, err
+	}
+	defer cleanup()
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return 0, fmt.Errorf("opening zip reader: %w", err)
+	}
+	imported := 0
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return imported, fmt.Errorf("opening %s: %w", f.Name, err)
+		}
+		err = importEntry(ctx, s, prefix, f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return imported, err
+		}
+		imported++
+	}
+	return imported, nil
+}
+
+// Returns r as an io.ReaderAt along with its size, spooling it to a temporary
+// file unless it supports random access itself. cleanup removes the file.
+func readerAt(r io.Reader) (ra io.ReaderAt, size int64, cleanup func(), err error) {
+	if rs, ok := r.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		size, err := rs.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("determining size: %w", err)
+		}
+		return rs, size, func() {}, nil
+	}
+	f, err := os.CreateTemp("", "blob-import-*.zip")
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("creating temporary file: %w", err)
+	}
+	cleanup = func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	size, err = io.Copy(f, r)
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, fmt.Errorf("buffering archive: %w", err)
+	}
+	return f, size, cleanup, nil
+}
+
+// Streams a single archive entry into a blob.
+func importEntry(ctx context.Context, s Storage, prefix, name string, r io.Reader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rel := strings.TrimPrefix(name, "./")
+	if strings.Contains(rel, `\`) || !fs.ValidPath(rel) || rel == "." {
+		return fmt.Errorf("unsafe entry name %q", name)
+	}
+	// A streaming write aborts instead of committing a truncated blob when
+	// the entry fails to read, such as for a corrupt or truncated archive.
+	key := prefix + rel
+	if err := s.WriteStream(ctx, key, r); err != nil {
+		return fmt.Errorf("writing %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package blob_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestZipFolder(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_zip_folder"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	files := map[string]string{
+		"users/123/a.txt":        "file a",
+		"users/123/photos/b.png": "file b",
+		"users/1234/c.txt":       "other user",
+	}
+	for key, data := range files {
+		if err := localFS.Write(ctx, key, []byte(data)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := blob.ZipFolder(ctx, localFS, "users/123", &buf); err != nil {
+		t.Fatalf("ZipFolder failed: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Opening zip failed: %v", err)
+	}
+	if len(zr.File) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(zr.File))
+	}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Opening entry failed: %v", err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if files["users/123/"+f.Name] != string(data) {
+			t.Fatalf("Unexpected content for %s: %s", f.Name, data)
+		}
+	}
+}
+
+func TestImportArchive(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_import_archive"
+	defer os.RemoveAll(basePath)
+
+	// Round trip through a zip export
+	src := blob.NewFsStorage(basePath + "/src")
+	for key, data := range map[string]string{"site/index.html": "index", "site/css/app.css": "css"} {
+		if err := src.Write(ctx, key, []byte(data)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	var zipped bytes.Buffer
+	if err := blob.ZipFolder(ctx, src, "site", &zipped); err != nil {
+		t.Fatalf("ZipFolder failed: %v", err)
+	}
+	dst := blob.NewFsStorage(basePath + "/dst")
+	n, err := blob.ImportArchive(ctx, dst, "restored", &zipped, blob.ArchiveZip)
+	if err != nil || n != 2 {
+		t.Fatalf("ImportArchive of zip = %d, %v", n, err)
+	}
+	data, err := dst.Read(ctx, "restored/css/app.css")
+	if err != nil || string(data) != "css" {
+		t.Fatalf("Read of imported blob = %q, %v", data, err)
+	}
+
+	// Tar entries escaping the prefix are rejected
+	var tarred bytes.Buffer
+	tw := tar.NewWriter(&tarred)
+	tw.WriteHeader(&tar.Header{Name: "./docs/", Typeflag: tar.TypeDir, Mode: 0o755})
+	tw.WriteHeader(&tar.Header{Name: "./docs/a.txt", Typeflag: tar.TypeReg, Mode: 0o644, Size: 1})
+	tw.Write([]byte("a"))
+	tw.WriteHeader(&tar.Header{Name: "../evil.txt", Typeflag: tar.TypeReg, Mode: 0o644, Size: 1})
+	tw.Write([]byte("x"))
+	tw.Close()
+	n, err = blob.ImportArchive(ctx, dst, "tar", &tarred, blob.ArchiveTar)
+	if err == nil || n != 1 {
+		t.Fatalf("ImportArchive with unsafe entry = %d, %v, want 1 and an error", n, err)
+	}
+	if exists, _ := dst.Exists(ctx, "tar/docs/a.txt"); !exists {
+		t.Fatalf("Safe tar entry was not imported")
+	}
+}
+
+func TestImportArchive_Truncated(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_import_archive_truncated"
+	defer os.RemoveAll(basePath)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	data := bytes.Repeat([]byte("x"), 4096)
+	tw.WriteHeader(&tar.Header{Name: "big.bin", Mode: 0o644, Size: int64(len(data))})
+	tw.Write(data)
+	tw.Close()
+	truncated := buf.Bytes()[:512+1024] // Header and part of the content
+
+	localFS := blob.NewFsStorage(basePath)
+	if _, err := blob.ImportArchive(ctx, localFS, "imports/", bytes.NewReader(truncated), blob.ArchiveTar); err == nil {
+		t.Fatalf("Expected an error for a truncated archive")
+	}
+	if exists, err := localFS.Exists(ctx, "imports/big.bin"); err != nil || exists {
+		t.Fatalf("Expected no truncated blob to be committed, got exists %v, %v", exists, err)
+	}
+}
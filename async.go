@@ -0,0 +1,240 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Returned by AsyncStorage.Write when the queue is full and the storage was
+// created with WithRejectWhenFull.
+var ErrQueueFull = errors.New("blob: write queue full")
+
+// Returned when writing to an AsyncStorage after it was closed.
+var ErrClosed = errors.New("blob: storage closed")
+
+// Default number of writes an AsyncStorage queues before applying backpressure.
+const DefaultAsyncQueueSize = 1024
+
+// Configures NewAsyncStorage.
+type AsyncOption func(*AsyncStorage)
+
+// Sets the number of writes that can be queued before Write blocks or fails.
+func WithQueueSize(n int) AsyncOption {
+	return func(a *AsyncStorage) {
+		a.queueSize = n
+	}
+}
+
+// Makes Write fail with ErrQueueFull instead of blocking when the queue is
+// full.
+func WithRejectWhenFull() AsyncOption {
+	return func(a *AsyncStorage) {
+		a.rejectWhenFull = true
+	}
+}
+
+// A queued write, or a flush marker if done is set.
+type asyncWrite struct {
+	ctx  context.Context
+	key  string
+	data []byte
+	done chan struct{}
+}
+
+// Storage that performs writes in the background. Writes are applied one at a
+// time in the order they were queued. Other modifications, such as Remove,
+// RemoveFolder, WriteIfMissing and streaming writes, first wait for the writes
+// queued before them, so a queued write never lands after them. Reads go
+// directly to the wrapped storage and may not observe queued writes, so call
+// Flush first when they need to.
+type AsyncStorage struct {
+	Storage
+	queueSize      int
+	rejectWhenFull bool
+
+	queue   chan asyncWrite
+	stopped chan struct{}
+
+	mu     sync.RWMutex // Guards closed against sending on a closed queue.
+	closed bool
+
+	errMu    sync.Mutex
+	firstErr error
+}
+
+// Wraps s so that Write returns once the write is queued, trading durability
+// for latency. When the queue is full, Write blocks until there is room, or
+// fails with ErrQueueFull when using WithRejectWhenFull. Close must be called
+// to write the remaining queued writes.
+func NewAsyncStorage(s Storage, opts ...AsyncOption) *AsyncStorage {
+	a := &AsyncStorage{Storage: s, queueSize: DefaultAsyncQueueSize}
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.queue = make(chan asyncWrite, a.queueSize)
+	a.stopped = make(chan struct{})
+	go a.run()
+	return a
+}
+
+// Queues a write of the blob. Errors of the write itself are reported by
+// Flush and Close. The write is performed with ctx even after it is
+// cancelled, so cancelling only aborts waiting for room in the queue.
+func (a *AsyncStorage) Write(ctx context.Context, key string, data []byte) error {
+	// The caller may reuse data once Write returns.
+	w := asyncWrite{ctx: context.WithoutCancel(ctx), key: key, data: append([]byte(nil), data...)}
+	return a.enqueue(ctx, w, a.rejectWhenFull)
+}
+
+// Writes a blob directly, after the queued writes, if the key does not contain
+// any data yet.
+func (a *AsyncStorage) WriteIfMissing(ctx context.Context, key string, data []byte) error {
+	if err := a.wait(ctx); err != nil {
+		return err
+	}
+	return a.Storage.WriteIfMissing(ctx, key, data)
+}
+
+// Writes a blob directly, after the queued writes, if the key does not contain
+// any data yet and reports whether it did, keeping WriteNext atomic.
+func (a *AsyncStorage) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	if err := a.wait(ctx); err != nil {
+		return false, err
+	}
+	return writeIfMissing(ctx, a.Storage, key, data)
+}
+
+// Removes a blob directly, after the queued writes.
+func (a *AsyncStorage) Remove(ctx context.Context, key string) error {
+	if err := a.wait(ctx); err != nil {
+		return err
+	}
+	return a.Storage.Remove(ctx, key)
+}
+
+// Removes a folder directly, after the queued writes.
+func (a *AsyncStorage) RemoveFolder(ctx context.Context, folder string) error {
+	if err := a.wait(ctx); err != nil {
+		return err
+	}
+	return a.Storage.RemoveFolder(ctx, folder)
+}
+
+// Returns a writer of the wrapped storage, after the queued writes.
+func (a *AsyncStorage) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	if err := a.wait(ctx); err != nil {
+		return nil, err
+	}
+	return a.Storage.Writer(ctx, key)
+}
+
+// Streams a blob directly, after the queued writes.
+func (a *AsyncStorage) WriteStream(ctx context.Context, key string, r io.Reader) error {
+	if err := a.wait(ctx); err != nil {
+		return err
+	}
+	return a.Storage.WriteStream(ctx, key, r)
+}
+
+// Waits until all writes queued before the call are written and returns the
+// first error of any write so far.
+func (a *AsyncStorage) Flush(ctx context.Context) error {
+	if err := a.flush(ctx); err != nil {
+		return err
+	}
+	return a.err()
+}
+
+// Waits until all writes queued before the call are written, ignoring their
+// errors, which Flush and Close report. After Close nothing is queued any
+// more, so it only waits for the remaining writes.
+func (a *AsyncStorage) wait(ctx context.Context) error {
+	err := a.flush(ctx)
+	if errors.Is(err, ErrClosed) {
+		select {
+		case <-a.stopped:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// Waits until all writes queued before the call are written.
+func (a *AsyncStorage) flush(ctx context.Context) error {
+	done := make(chan struct{})
+	if err := a.enqueue(ctx, asyncWrite{done: done}, false); err != nil {
+		return err
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stops accepting writes, waits until all queued writes are written and
+// returns the first error of any write.
+func (a *AsyncStorage) Close() error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+	<-a.stopped
+	return a.err()
+}
+
+// Adds w to the queue, blocking while the queue is full unless reject is set.
+func (a *AsyncStorage) enqueue(ctx context.Context, w asyncWrite, reject bool) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return ErrClosed
+	}
+	if reject {
+		select {
+		case a.queue <- w:
+			return nil
+		default:
+			return fmt.Errorf("%w: %d writes queued", ErrQueueFull, a.queueSize)
+		}
+	}
+	select {
+	case a.queue <- w:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Applies queued writes until the queue is closed.
+func (a *AsyncStorage) run() {
+	defer close(a.stopped)
+	for w := range a.queue {
+		if w.done != nil {
+			close(w.done)
+			continue
+		}
+		if err := a.Storage.Write(w.ctx, w.key, w.data); err != nil {
+			a.errMu.Lock()
+			if a.firstErr == nil {
+				a.firstErr = fmt.Errorf("writing %s: %w", w.key, err)
+			}
+			a.errMu.Unlock()
+		}
+	}
+}
+
+// Returns the first error of any write so far.
+func (a *AsyncStorage) err() error {
+	a.errMu.Lock()
+	defer a.errMu.Unlock()
+	return a.firstErr
+}
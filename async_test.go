@@ -0,0 +1,97 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/blob-go"
+)
+
+// Blocks writes until release is closed.
+type blockedWrites struct {
+	blob.Storage
+	release chan struct{}
+}
+
+func (s *blockedWrites) Write(ctx context.Context, key string, data []byte) error {
+	<-s.release
+	return s.Storage.Write(ctx, key, data)
+}
+
+func TestAsyncStorage(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_async_storage"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	backend := &blockedWrites{Storage: localFS, release: make(chan struct{})}
+	s := blob.NewAsyncStorage(backend, blob.WithQueueSize(1), blob.WithRejectWhenFull())
+
+	// The first write is picked up by the worker and blocks there, the second
+	// fills the queue and the third is rejected.
+	if err := s.Write(ctx, "queue/1", []byte("1")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	var err error
+	for time.Now().Before(deadline) {
+		if err = s.Write(ctx, "queue/2", []byte("2")); err == nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := s.Write(ctx, "queue/3", []byte("3")); !errors.Is(err, blob.ErrQueueFull) {
+		t.Fatalf("Write to full queue should return ErrQueueFull, got: %v", err)
+	}
+
+	close(backend.release)
+	if err := s.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	keys, err := localFS.List(ctx, "queue/")
+	if err != nil || len(keys) != 2 {
+		t.Fatalf("Expected 2 written blobs after Flush, got %v, %v", keys, err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := s.Write(ctx, "queue/4", []byte("4")); !errors.Is(err, blob.ErrClosed) {
+		t.Fatalf("Write after Close should return ErrClosed, got: %v", err)
+	}
+}
+
+func TestAsyncStorage_RemoveAfterWrite(t *testing.T) {
+	ctx := context.Background()
+	m := blob.NewMemStorage()
+	backend := &blockedWrites{Storage: m, release: make(chan struct{})}
+	s := blob.NewAsyncStorage(backend)
+	defer s.Close()
+
+	if err := s.Write(ctx, "doc", []byte("data")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	removed := make(chan error, 1)
+	go func() { removed <- s.Remove(ctx, "doc") }()
+	select {
+	case err := <-removed:
+		t.Fatalf("Remove returned before the queued write, with %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(backend.release)
+	if err := <-removed; err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := s.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if exists, _ := m.Exists(ctx, "doc"); exists {
+		t.Fatal("The queued write brought back the removed blob")
+	}
+}
@@ -0,0 +1,73 @@
+package blob
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"os"
+	"path"
+)
+
+// Stores the content type that Stat and ContentType detect for Fs blobs
+// without a recorded one, such as blobs written before content types were
+// tracked, in their metadata sidecar, so later reads skip the detection.
+//
+// The backfill writes to the store during a read, which is why it is opt-in.
+// It is best-effort: a failed sidecar write is logged and the detected type
+// is still returned, and a write racing with the backfill may be tagged with
+// the type detected for the previous content.
+func WithContentTypeBackfill() FsOption {
+	return fsOptionFunc(func(l *Fs) {
+		l.backfillContentType = true
+	})
+}
+
+// Returns the content type of m, detecting a missing one.
+func (l *Fs) contentType(key, filePath string, m fsMeta) (string, error) {
+	if m.ContentType != "" {
+		return m.ContentType, nil
+	}
+	return l.detectContentType(key, filePath, m)
+}
+
+// Detects the content type of the file at filePath and, with
+// WithContentTypeBackfill, stores it in the sidecar of key.
+func (l *Fs) detectContentType(key, filePath string, m fsMeta) (string, error) {
+	contentType, err := sniffContentType(key, filePath, m)
+	if err != nil || !l.backfillContentType {
+		return contentType, err
+	}
+	// Re-read the metadata to keep what a concurrent write stored meanwhile.
+	current, err := l.readMeta(key)
+	if err == nil && current.ContentType == "" {
+		current.ContentType = contentType
+		err = l.writeMeta(key, current)
+	}
+	if err != nil {
+		slog.Warn("blob: backfilling content type failed", "key", key, "error", err)
+	}
+	return contentType, nil
+}
+
+// Detects the content type of the file at filePath from the extension of key
+// or its first 512 bytes. Encoded content is only detected by extension.
+func sniffContentType(key, filePath string, m fsMeta) (string, error) {
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		return contentType, nil
+	}
+	if m.ContentEncoding != "" {
+		return DefaultContentType, nil
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("opening file: %w", wrapNotFound(err))
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+	return DetectContentType(head[:n]), nil
+}
@@ -0,0 +1,64 @@
+package blob_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestLocalFiles_ContentTypeBackfill(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_content_type_backfill"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	// Files written without Fs have no recorded content type.
+	for key, data := range map[string]string{"pages/index.html": "hello", "legacy/doc": "%PDF-1.7"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(basePath, key)), 0o755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(basePath, key), []byte(data), 0o644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	// Without backfill the type is detected on every read, so it follows the
+	// content.
+	if contentType, err := localFS.ContentType(ctx, "legacy/doc"); err != nil || contentType != "application/pdf" {
+		t.Fatalf("Expected the sniffed type without backfill, got %q, %v", contentType, err)
+	}
+	if err := os.WriteFile(filepath.Join(basePath, "legacy/doc"), []byte("GIF89a"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if contentType, err := localFS.ContentType(ctx, "legacy/doc"); err != nil || contentType != "image/gif" {
+		t.Fatalf("Expected the type of the changed content without backfill, got %q, %v", contentType, err)
+	}
+	if err := os.WriteFile(filepath.Join(basePath, "legacy/doc"), []byte("%PDF-1.7"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	backfilling := blob.NewFsStorage(basePath, blob.WithContentTypeBackfill())
+	info, err := backfilling.Stat(ctx, "pages/index.html")
+	if err != nil || info.ContentType != "text/html; charset=utf-8" {
+		t.Fatalf("Expected the type of the extension, got %+v, %v", info, err)
+	}
+	if contentType, err := backfilling.ContentType(ctx, "legacy/doc"); err != nil || contentType != "application/pdf" {
+		t.Fatalf("Expected the sniffed type, got %q, %v", contentType, err)
+	}
+
+	// The detected types are stored, so they no longer follow the content.
+	if err := os.WriteFile(filepath.Join(basePath, "legacy/doc"), []byte("GIF89a"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	for key, want := range map[string]string{"pages/index.html": "text/html; charset=utf-8", "legacy/doc": "application/pdf"} {
+		if contentType, err := localFS.ContentType(ctx, key); err != nil || contentType != want {
+			t.Fatalf("ContentType of %s after backfill = %q, %v, want %q", key, contentType, err, want)
+		}
+	}
+	if data, err := localFS.Read(ctx, "legacy/doc"); err != nil || string(data) != "GIF89a" {
+		t.Fatalf("Read after backfill = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,229 @@
+package blob
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of BatchWriterOptions.
+const (
+	DefaultBatchWindow   = time.Minute
+	DefaultBatchMaxBytes = 1 << 20
+)
+
+// Layout of the window folder of batch objects, in UTC.
+const batchWindowFormat = "20060102T150405Z"
+
+// Options of NewBatchWriter. Zero fields use the defaults.
+type BatchWriterOptions struct {
+	// Folder the batch objects are written to, default "batches/".
+	Prefix string
+	// Length of the time windows entries are grouped by, DefaultBatchWindow
+	// by default. Buffered entries are flushed when their window ends.
+	Window time.Duration
+	// Flushes once the buffered data reaches this size, DefaultBatchMaxBytes
+	// by default.
+	MaxBytes int
+}
+
+// An individual write stored in a batch object.
+type BatchEntry struct {
+	Key  string    `json:"key"`
+	Data []byte    `json:"data"`
+	Time time.Time `json:"time"`
+}
+
+// Storage that combines writes into batch objects, see NewBatchWriter.
+type BatchWriter struct {
+	Storage
+	opts BatchWriterOptions
+
+	mu      sync.Mutex // Guards the buffer.
+	window  time.Time  // Start of the window of the buffered entries.
+	entries []BatchEntry
+	size    int
+
+	flushMu sync.Mutex // Serializes flushes, so batches keep their order.
+	bgErr   error      // First unreported error of a background flush, guarded by mu.
+
+	stop    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// Wraps s so that Write buffers entries in memory and stores them together as
+// a single NDJSON batch object per flush, cutting the number of objects and
+// requests of workloads with many tiny writes, such as metric points.
+//
+// Batch objects are written to <Prefix><window start>/<sequence>, e.g.
+// batches/20240501T120000Z/000001, with WriteNext, so concurrent flushes,
+// even from several processes, never overwrite each other. Each line is a
+// JSON encoded BatchEntry. Entries are not readable at their own key: use
+// ReadBatches to retrieve them.
+//
+// Buffered entries are lost if the process dies before they are flushed,
+// which happens when their window ends, the buffer reaches MaxBytes, or on
+// Flush and Close. Write returns before the entry is durable, and errors of
+// flushes in the background are reported by the next Flush or Close, with
+// the entries of the failed flush dropped. Close must be called to persist
+// the remaining entries.
+func NewBatchWriter(s Storage, opts BatchWriterOptions) *BatchWriter {
+	if opts.Prefix == "" {
+		opts.Prefix = "batches/"
+	}
+	opts.Prefix = strings.TrimSuffix(opts.Prefix, "/") + "/"
+	if opts.Window <= 0 {
+		opts.Window = DefaultBatchWindow
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultBatchMaxBytes
+	}
+	b := &BatchWriter{Storage: s, opts: opts, stop: make(chan struct{}), stopped: make(chan struct{})}
+	go b.run()
+	return b
+}
+
+// Buffers a write of data to key, flushing the buffer first if the entry
+// belongs to a new window and afterwards if it reached MaxBytes.
+func (b *BatchWriter) Write(ctx context.Context, key string, data []byte) error {
+	now := time.Now().UTC()
+	window := now.Truncate(b.opts.Window)
+	b.mu.Lock()
+	if len(b.entries) > 0 && !window.Equal(b.window) {
+		b.mu.Unlock()
+		if err := b.Flush(ctx); err != nil {
+			return err
+		}
+		b.mu.Lock()
+	}
+	if len(b.entries) == 0 {
+		b.window = window
+	}
+	// The caller may reuse data once Write returns.
+	b.entries = append(b.entries, BatchEntry{Key: key, Data: bytes.Clone(data), Time: now})
+	b.size += len(key) + len(data)
+	full := b.size >= b.opts.MaxBytes
+	b.mu.Unlock()
+	if full {
+		return b.Flush(ctx)
+	}
+	return nil
+}
+
+// Writes a blob directly if the key does not contain any data yet and reports
+// whether it did. Like WriteIfMissing it bypasses the batches.
+func (b *BatchWriter) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	return writeIfMissing(ctx, b.Storage, key, data)
+}
+
+// Writes the buffered entries as a batch object. Returns its error, or else
+// the error of a background flush since the last call. The entries of a
+// failed flush are dropped.
+func (b *BatchWriter) Flush(ctx context.Context) error {
+	if err := b.flush(ctx); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := b.bgErr
+	b.bgErr = nil
+	return err
+}
+
+// Writes the buffered entries as a batch object.
+func (b *BatchWriter) flush(ctx context.Context) error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+	b.mu.Lock()
+	entries, window := b.entries, b.window
+	b.entries, b.size = nil, 0
+	b.mu.Unlock()
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return fmt.Errorf("encoding entry: %w", err)
+		}
+	}
+	folder := b.opts.Prefix + window.Format(batchWindowFormat)
+	if _, err := WriteNext(ctx, b.Storage, folder, buf.Bytes()); err != nil {
+		return fmt.Errorf("writing batch of %d entries: %w", len(entries), err)
+	}
+	return nil
+}
+
+// Stops the background flushes and writes the remaining buffered entries.
+func (b *BatchWriter) Close() error {
+	b.once.Do(func() { close(b.stop) })
+	<-b.stopped
+	return b.Flush(context.Background())
+}
+
+// Flushes the buffer when the window of its entries ended.
+func (b *BatchWriter) run() {
+	defer close(b.stopped)
+	ticker := time.NewTicker(min(b.opts.Window, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case now := <-ticker.C:
+			b.mu.Lock()
+			due := len(b.entries) > 0 && !now.UTC().Before(b.window.Add(b.opts.Window))
+			b.mu.Unlock()
+			if !due {
+				continue
+			}
+			if err := b.flush(context.Background()); err != nil {
+				b.mu.Lock()
+				if b.bgErr == nil {
+					b.bgErr = err
+				}
+				b.mu.Unlock()
+			}
+		}
+	}
+}
+
+// Calls fn with every entry of the batch objects under prefix, the Prefix of
+// a BatchWriter, in the order the entries were flushed. An error from fn
+// stops reading and is returned as is.
+func ReadBatches(ctx context.Context, s Storage, prefix string, fn func(BatchEntry) error) error {
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	keys, err := s.List(ctx, prefix)
+	if err != nil {
+		return fmt.Errorf("listing batches: %w", err)
+	}
+	for _, key := range keys {
+		data, err := s.Read(ctx, key)
+		if err != nil {
+			return fmt.Errorf("reading batch %s: %w", key, err)
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(nil, len(data)+1)
+		for scanner.Scan() {
+			var entry BatchEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				return fmt.Errorf("decoding entry of batch %s: %w", key, err)
+			}
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("scanning batch %s: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestBatchWriter(t *testing.T) {
+	ctx := context.Background()
+	mem := blob.NewMemStorage()
+	b := blob.NewBatchWriter(mem, blob.BatchWriterOptions{Prefix: "metrics", MaxBytes: 20})
+
+	for i := range 10 {
+		if err := b.Write(ctx, fmt.Sprintf("cpu/%d", i), []byte("0.5")); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := mem.Read(ctx, "cpu/0"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Batched entries should not be stored at their own key, got: %v", err)
+	}
+	batches, err := mem.List(ctx, "metrics/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	// 10 entries of 8 bytes with a flush at 20 bytes give at least 4 batches,
+	// more if a window ended in between.
+	if len(batches) < 4 || len(batches) > 10 {
+		t.Fatalf("Expected a few batch objects, got %v", batches)
+	}
+
+	var keys []string
+	err = blob.ReadBatches(ctx, mem, "metrics/", func(entry blob.BatchEntry) error {
+		if string(entry.Data) != "0.5" || entry.Time.IsZero() {
+			t.Errorf("Unexpected entry: %+v", entry)
+		}
+		keys = append(keys, entry.Key)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadBatches failed: %v", err)
+	}
+	for i, key := range keys {
+		if want := fmt.Sprintf("cpu/%d", i); key != want {
+			t.Fatalf("Entry %d has key %s, want %s", i, key, want)
+		}
+	}
+	if len(keys) != 10 {
+		t.Fatalf("Expected 10 entries, got %d", len(keys))
+	}
+}
@@ -1,22 +1,39 @@
 package blob
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"cloud.google.com/go/storage"
+	"github.com/googleapis/gax-go/v2"
 	"golang.org/x/sync/errgroup"
-	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
 )
 
 // A simplified interface for interacting with blob storage.
 type Storage interface {
-	// Reads a blob
+	// Reads a blob, buffering all of it in memory however large it is. See
+	// ReadLimit for reading keys that may point at huge blobs.
 	Read(ctx context.Context, key string) ([]byte, error)
 	// Writes a blob
 	Write(ctx context.Context, key string, data []byte) error
@@ -24,56 +41,295 @@ type Storage interface {
 	WriteIfMissing(ctx context.Context, key string, data []byte) error
 	// Removes a blob if it exists
 	Remove(ctx context.Context, key string) error
-	// Removes a folder and all children blobs
+	// Removes a folder and all children blobs. The root folder, "" or "/",
+	// is refused with ErrInvalidKey.
 	RemoveFolder(ctx context.Context, folder string) error
+	// Lists the keys of all blobs starting with prefix, sorted by key
+	List(ctx context.Context, prefix string) ([]string, error)
 
 	// Returns an io readerCloser
 	Reader(ctx context.Context, key string) (io.ReadCloser, error)
+	// Streams a blob without loading it into memory. The caller must close
+	// the returned reader. A missing blob fails with ErrNotFound before any
+	// data is read.
+	ReadStream(ctx context.Context, key string) (io.ReadCloser, error)
 	// Returns an io writerCloser
 	Writer(ctx context.Context, key string) (io.WriteCloser, error)
+	// Writes a blob with the data read from r until EOF, without loading it
+	// into memory.
+	WriteStream(ctx context.Context, key string, r io.Reader) error
 }
 
+// Returned when a blob does not exist.
+var ErrNotFound = errors.New("blob: not found")
+
+// Returned when a conditional operation fails because its precondition does
+// not hold.
+var ErrPreconditionFailed = errors.New("blob: precondition failed")
+
+// Returned when a key collides with an existing key that only differs in case
+// on a case-insensitive file system.
+var ErrCaseCollision = errors.New("blob: key collides with a key differing in case")
+
+// Returned when a key exceeds the key length limit of a backend.
+var ErrKeyTooLong = errors.New("blob: key too long")
+
+// Returned when a blob cannot be removed because it is still retained.
+var ErrRetained = errors.New("blob: retained")
+
+// Describes a stored blob.
+type BlobInfo struct {
+	Key     string    // Key of the blob, relative to the storage root.
+	Size    int64     // Size in bytes.
+	ModTime time.Time // Last modification time.
+
+	// Custom metadata, only set by Stat and by listings that include it, see
+	// WithListMetadata.
+	Metadata map[string]string
+	// MIME type of the content and an opaque identifier of the stored
+	// version for cache validation, only set by Stat.
+	ContentType string
+	ETag        string
+	// Compression and caching directive the blob was written with, only set
+	// by Stat.
+	ContentEncoding string
+	CacheControl    string
+}
+
+// Options for writing a blob. Zero fields are not applied.
+type WriteOptions struct {
+	// MIME type of the blob, e.g. "application/json".
+	ContentType string
+	// Encoding the data was compressed with, e.g. "gzip".
+	ContentEncoding string
+	// Caching directive for clients and CDNs serving the blob, e.g.
+	// "public, max-age=3600".
+	CacheControl string
+	// Custom key-value metadata stored with the blob.
+	Metadata map[string]string
+	// Prevents the blob from being deleted or overwritten until this time.
+	// On GCS this requires object retention to be enabled on the bucket.
+	RetainUntil time.Time
+	// Locks the retention so that it cannot be shortened or removed, not even
+	// by the bucket owner. Only used together with RetainUntil.
+	RetentionLocked bool
+	// Only writes if the blob currently has this generation and fails with
+	// ErrPreconditionFailed otherwise, where zero means the blob must not
+	// exist. Conditioning retries on the generation read before the first
+	// attempt makes them idempotent: a retry fails instead of overwriting a
+	// newer version if the first attempt succeeded unacknowledged. Nil writes
+	// unconditionally. See Gcs.Generation and Fs.Generation.
+	IfGenerationMatch *int64
+}
+
+// Reports whether err indicates a missing blob for any of the backends.
+func isNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound) || errors.Is(err, fs.ErrNotExist) || errors.Is(err, storage.ErrObjectNotExist)
+}
+
+// Rejects a folder that stands for the whole storage, such as "", "/" or ".",
+// so that a bug upstream cannot remove every blob with RemoveFolder.
+func checkFolder(folder string) error {
+	if path.Clean("/"+folder) == "/" {
+		return fmt.Errorf("%w: refusing to remove the root folder %q", ErrInvalidKey, folder)
+	}
+	return nil
+}
+
+// Writes the data read from r to key through the Writer of s, for storages
+// without a native streaming write. Cancelling the write context on a failed
+// copy aborts uploads that have not been committed yet.
+func writeStream(ctx context.Context, s Storage, key string, r io.Reader) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	wc, err := s.Writer(ctx, key)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(wc, r); err != nil {
+		cancel()
+		wc.Close()
+		return fmt.Errorf("copying: %w", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("closing writer: %w", err)
+	}
+	return nil
+}
+
+// Wraps a backend error indicating a missing blob so that it matches
+// ErrNotFound, other errors are returned as is.
+func wrapNotFound(err error) error {
+	if isNotFound(err) && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	return err
+}
+
+// Maximum length in bytes of each slash-separated component of an Fs key,
+// which is the file name limit of most file systems.
+const FsMaxKeyComponentLength = 255
+
 // Implements the Storage interface for the local file system.
 type Fs struct {
-	basePath string // Base path where blobs will be stored.
+	basePath     string // Base path where blobs will be stored.
+	caseEncoding bool   // Whether to encode upper case letters in file names.
+	checksums    bool   // Whether to store checksums in metadata sidecars.
+	verify       bool   // Whether to verify checksums on read.
+
+	autoDecompress bool // Whether to decompress blobs by their content encoding.
+	fsync          bool // Whether to sync written files to disk before returning.
+
+	backfillContentType bool // Whether to detect and store missing content types.
+
+	timeout time.Duration // Default timeout of operations without a deadline.
 }
 
+// Configures an Fs instance.
+type FsOption interface {
+	applyFs(*Fs)
+}
+
+// Adapts a function to an FsOption.
+type fsOptionFunc func(*Fs)
+
+func (f fsOptionFunc) applyFs(l *Fs) { f(l) }
+
 // Returns a new Fs instance.
-func NewFsStorage(basePath string) *Fs {
-	return &Fs{
+func NewFsStorage(basePath string, opts ...FsOption) *Fs {
+	l := &Fs{
 		basePath: basePath,
 	}
+	for _, opt := range opts {
+		opt.applyFs(l)
+	}
+	return l
 }
 
 // Reads a blob from the local file system.
-func (l *Fs) Read(ctx context.Context, key string) ([]byte, error) {
-	path := filepath.Join(l.basePath, key)
-	return os.ReadFile(path)
+func (l *Fs) Read(ctx context.Context, key string) (_ []byte, err error) {
+	defer annotate(&err, "fs", "Read", key)
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	path, err := l.filePath(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !l.verify && !l.autoDecompress {
+		return data, nil
+	}
+	m, err := l.readMeta(key)
+	if err != nil {
+		return nil, err
+	}
+	if l.verify && m.SHA256 != "" {
+		h := sha256.New()
+		h.Write(data)
+		if err := checkSum(key, h, m.SHA256); err != nil {
+			return nil, err
+		}
+	}
+	if l.autoDecompress {
+		return decompressBytes(key, data, m.ContentEncoding)
+	}
+	return data, nil
 }
 
-// Writes a blob to the local file system.
-func (l *Fs) Write(ctx context.Context, key string, data []byte) error {
-	path := filepath.Join(l.basePath, key)
-	dir := filepath.Dir(path) // Ensure directory exists
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return fmt.Errorf("creating directory: %w", err)
+// Writes a blob to the local file system. The blob is replaced atomically, so
+// concurrent readers see either the previous or the complete new data.
+func (l *Fs) Write(ctx context.Context, key string, data []byte) (err error) {
+	defer annotate(&err, "fs", "Write", key)
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return l.write(key, data, l.newMeta(data))
+}
+
+// Writes a blob to the local file system, storing the content type, encoding,
+// cache control and metadata in its metadata sidecar, from where Stat returns
+// them. Retention is not supported.
+func (l *Fs) WriteWithOptions(ctx context.Context, key string, data []byte, opts WriteOptions) error {
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !opts.RetainUntil.IsZero() {
+		return fmt.Errorf("retention on the local file system: %w", errors.ErrUnsupported)
+	}
+	m := l.newMeta(data)
+	m.ContentType = cmp.Or(opts.ContentType, DefaultContentType)
+	m.ContentEncoding = opts.ContentEncoding
+	m.CacheControl = opts.CacheControl
+	m.Metadata = maps.Clone(opts.Metadata)
+	if opts.IfGenerationMatch != nil {
+		return l.writeIfGeneration(ctx, key, data, m, *opts.IfGenerationMatch)
 	}
-	return os.WriteFile(path, data, 0o644)
+	return l.write(key, data, m)
+}
+
+// Writes a blob and its metadata through a temporary file, see writeFrom.
+func (l *Fs) write(key string, data []byte, m fsMeta) error {
+	return l.writeFrom(key, bytes.NewReader(data), m)
 }
 
 // Writes a blob to the local file system if the key does not contain any data yet
-func (l *Fs) WriteIfMissing(ctx context.Context, key string, data []byte) error {
-	path := filepath.Join(l.basePath, key)
+func (l *Fs) WriteIfMissing(ctx context.Context, key string, data []byte) (err error) {
+	defer annotate(&err, "fs", "WriteIfMissing", key)
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	_, err = l.writeIfMissing(ctx, key, data)
+	return err
+}
+
+// Writes a blob to the local file system if the key does not contain any data
+// yet and reports whether this call created it, such as to run first-time
+// initialization only once.
+func (l *Fs) WriteIfMissingOK(ctx context.Context, key string, data []byte) (_ bool, err error) {
+	defer annotate(&err, "fs", "WriteIfMissingOK", key)
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	return l.writeIfMissing(ctx, key, data)
+}
+
+// Writes a blob if the key does not contain any data yet and reports whether
+// it did.
+func (l *Fs) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	path, err := l.filePath(key)
+	if err != nil {
+		return false, err
+	}
 	dir := filepath.Dir(path) // Ensure directory exists
 	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return fmt.Errorf("creating directory: %w", err)
+		return false, fmt.Errorf("creating directory: %w", err)
 	}
 	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
 	if err != nil {
 		if os.IsExist(err) {
-			return nil // File already exists
+			// A case-insensitive file system also reports a file whose name
+			// only differs in case as existing.
+			exact, err := exactNameExists(path)
+			if err != nil {
+				return false, fmt.Errorf("checking file name: %w", err)
+			}
+			if !exact {
+				return false, fmt.Errorf("%w: %s", ErrCaseCollision, key)
+			}
+			return false, nil // File already exists
 		}
-		return fmt.Errorf("opening file with O_EXCL: %w", err)
+		return false, fmt.Errorf("opening file with O_EXCL: %w", err)
 	}
 	defer f.Close()
 
@@ -81,40 +337,172 @@ func (l *Fs) WriteIfMissing(ctx context.Context, key string, data []byte) error
 	// Now we can safely write to it.
 	_, err = f.WriteAt(data, 0)
 	if err != nil {
-		return fmt.Errorf("writing data: %w", err)
+		return true, fmt.Errorf("writing data: %w", err)
 	}
-	return nil
+	if err := l.syncFile(f); err != nil {
+		return true, err
+	}
+	if err := l.syncDir(dir); err != nil {
+		return true, err
+	}
+	return true, l.writeMeta(key, l.newMeta(data))
 }
 
 // Removes a blob from the local file system.
-func (l *Fs) Remove(ctx context.Context, key string) error {
-	path := filepath.Join(l.basePath, key)
-	return os.Remove(path)
+func (l *Fs) Remove(ctx context.Context, key string) (err error) {
+	defer annotate(&err, "fs", "Remove", key)
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, err := l.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("removing file: %w", wrapNotFound(err))
+	}
+	return l.removeMeta(key)
 }
 
-// Removes a folder
-func (l *Fs) RemoveFolder(ctx context.Context, folder string) error {
-	path := filepath.Join(l.basePath, folder)
-	err := os.RemoveAll(path)
+// Removes the blob at the given key only if it is empty and reports whether
+// it was removed. Missing and non-empty blobs are not an error. The local
+// file system offers no precondition, so a concurrent write between the
+// check and the removal is lost.
+func (l *Fs) RemoveIfEmpty(ctx context.Context, key string) (bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	path, err := l.filePath(key)
 	if err != nil {
-		return fmt.Errorf("removing folder: %w", err)
+		return false, err
 	}
-	return nil
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("stating file: %w", err)
+	}
+	if info.IsDir() || info.Size() > 0 {
+		return false, nil
+	}
+	if err := l.Remove(ctx, key); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Removes all blobs under the folder, but not a blob named like the folder.
+func (l *Fs) RemoveFolder(ctx context.Context, folder string) (err error) {
+	defer annotate(&err, "fs", "RemoveFolder", folder)
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	_, err = l.removeFolder(ctx, folder)
+	return err
+}
+
+// Removes a folder like RemoveFolder and returns the number of blobs removed,
+// such as for audit logs. The blobs are removed one by one while walking the
+// folder, so the count reflects the actual deletes.
+func (l *Fs) RemoveFolderCount(ctx context.Context, folder string) (_ int, err error) {
+	defer annotate(&err, "fs", "RemoveFolderCount", folder)
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	return l.removeFolder(ctx, folder)
+}
+
+// Removes the blobs of a folder and then its directories and metadata. Like on
+// Gcs, only keys under folder/ are removed, not a blob at folder itself.
+func (l *Fs) removeFolder(ctx context.Context, folder string) (int, error) {
+	if err := checkFolder(folder); err != nil {
+		return 0, err
+	}
+	path, err := l.filePath(folder)
+	if err != nil {
+		return 0, err
+	}
+	var removed int
+	err = l.walk(ctx, strings.TrimSuffix(folder, "/")+"/", func(key string, info fs.FileInfo) error {
+		file, err := l.filePath(key)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(file); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil // Removed concurrently
+			}
+			return err
+		}
+		removed++
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("removing files: %w", err)
+	}
+	if err := removeDir(path); err != nil {
+		return removed, fmt.Errorf("removing folder: %w", err)
+	}
+	metaPath, err := l.metaPath(folder)
+	if err != nil {
+		return removed, err
+	}
+	if err := removeDir(metaPath); err != nil {
+		return removed, fmt.Errorf("removing metadata folder: %w", err)
+	}
+	return removed, nil
+}
+
+// Removes the directory at path with everything in it. A file at path is
+// kept, as it is a blob with the folder's name rather than the folder.
+func removeDir(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return nil
+	}
+	return os.RemoveAll(path)
 }
 
 // Returns an io readerCloser for the blob at the given key.
-func (l *Fs) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
-	path := filepath.Join(l.basePath, key)
-	file, err := os.Open(path)
+func (l *Fs) Reader(ctx context.Context, key string) (_ io.ReadCloser, err error) {
+	defer annotate(&err, "fs", "Reader", key)
+	rc, err := l.open(key)
 	if err != nil {
 		return nil, fmt.Errorf("opening file: %w", err)
 	}
-	return file, nil
+	return rc, nil
+}
+
+// Streams the blob at the given key from its file. The caller must close the
+// returned reader.
+func (l *Fs) ReadStream(ctx context.Context, key string) (_ io.ReadCloser, err error) {
+	defer annotate(&err, "fs", "ReadStream", key)
+	rc, err := l.open(key)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", wrapNotFound(err))
+	}
+	return rc, nil
 }
 
 // Returns an io writerCloser for the blob at the given key.
-func (l *Fs) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
-	path := filepath.Join(l.basePath, key)
+func (l *Fs) Writer(ctx context.Context, key string) (_ io.WriteCloser, err error) {
+	defer annotate(&err, "fs", "Writer", key)
+	path, err := l.filePath(key)
+	if err != nil {
+		return nil, err
+	}
 	dir := filepath.Dir(path) // Ensure directory exists
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return nil, fmt.Errorf("creating directory: %w", err)
@@ -123,126 +511,1305 @@ func (l *Fs) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
 	if err != nil {
 		return nil, fmt.Errorf("creating file: %w", err)
 	}
-	return file, nil
+	w := &fsWriter{f: file, l: l, key: key}
+	if l.checksums {
+		w.h = sha256.New()
+	}
+	return w, nil
 }
 
-// Gcs implements Storage for Google Cloud Storage.
-type Gcs struct {
-	bucket *storage.BucketHandle
-	prefix string
+// Writes the data read from r to the blob at the given key. The data is
+// written to a temporary file next to the blob's file that is renamed to it
+// once complete, so a failed or partial write never leaves a half-written file
+// visible at the key.
+func (l *Fs) WriteStream(ctx context.Context, key string, r io.Reader) (err error) {
+	defer annotate(&err, "fs", "WriteStream", key)
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	return l.writeFrom(key, ctxReader{ctx: ctx, r: r}, fsMeta{ContentType: DefaultContentType})
 }
 
-// Returns a new Gcs blob storage instance.
-func NewGcsStorage(ctx context.Context, bucket string, prefix string) (*Gcs, error) {
-	client, err := storage.NewClient(ctx)
+// Prefix of the temporary files of writes, created in the directory of the
+// blob's file so the rename never crosses file systems. Listings skip them
+// and keys whose name starts with it are rejected.
+const fsTempPrefix = ".upload-"
+
+// Writes the data read from r to a temporary file that is renamed to the
+// blob's file once complete, then stores m as its metadata. The checksum of m
+// is replaced by the one of the data if checksums are enabled.
+func (l *Fs) writeFrom(key string, r io.Reader, m fsMeta) error {
+	path, err := l.filePath(key)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path) // Ensure directory exists
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+	f, err := os.CreateTemp(dir, fsTempPrefix+"*")
 	if err != nil {
-		return nil, fmt.Errorf("creating client: %w", err)
+		return fmt.Errorf("creating temporary file: %w", err)
+	}
+	defer os.Remove(f.Name()) // Fails harmlessly once renamed
+
+	w := &fsWriter{f: f, l: l, key: key}
+	if l.checksums {
+		w.h = sha256.New()
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		f.Close()
+		return fmt.Errorf("copying: %w", err)
+	}
+	if err := l.syncFile(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		return fmt.Errorf("setting file mode: %w", err)
 	}
-	return &Gcs{client.Bucket(bucket), prefix}, nil
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing temporary file: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("renaming temporary file: %w", err)
+	}
+	if err := l.syncDir(dir); err != nil {
+		return err
+	}
+	if w.h != nil {
+		m.SHA256 = hex.EncodeToString(w.h.Sum(nil))
+	}
+	return l.writeMeta(key, m)
 }
 
-// Reads a blob from Google Cloud Storage.
-func (g *Gcs) Read(ctx context.Context, key string) ([]byte, error) {
-	key = path.Join(g.prefix, key)
-	rc, err := g.bucket.Object(key).NewReader(ctx)
+// Copies the blob at the given key to w and returns the number of bytes written.
+// With WithVerifyChecksums the data is already copied when a mismatch is
+// detected, so callers must discard it on error.
+func (l *Fs) ReadTo(ctx context.Context, key string, w io.Writer) (int64, error) {
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	rc, err := l.open(key)
 	if err != nil {
-		return nil, fmt.Errorf("creating reader: %w", err)
+		return 0, fmt.Errorf("opening file: %w", wrapNotFound(err))
 	}
 	defer rc.Close()
 
-	return io.ReadAll(rc)
+	n, err := io.Copy(w, rc)
+	if err != nil {
+		return n, fmt.Errorf("copying: %w", err)
+	}
+	return n, nil
 }
 
-// Writes a blob to Google Cloud Storage.
-func (g *Gcs) Write(ctx context.Context, key string, data []byte) error {
-	key = path.Join(g.prefix, key)
-	wc := g.bucket.Object(key).NewWriter(ctx)
+// Copies length bytes of the blob at the given key, starting at offset, to w
+// and returns the number of bytes written. A negative length copies until the
+// end of the blob. Fewer bytes are copied if the blob ends before the range.
+func (l *Fs) ReadRangeTo(ctx context.Context, key string, offset, length int64, w io.Writer) (int64, error) {
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	path, err := l.filePath(key)
+	if err != nil {
+		return 0, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("opening file: %w", wrapNotFound(err))
+	}
+	defer file.Close()
 
-	if _, err := wc.Write(data); err != nil {
-		return fmt.Errorf("writing: %w", err)
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("seeking: %w", err)
 	}
-	if err := wc.Close(); err != nil {
-		return fmt.Errorf("closing writer: %w", err)
+	var n int64
+	if length < 0 {
+		n, err = io.Copy(w, file)
+	} else {
+		n, err = io.CopyN(w, file, length)
 	}
-	return nil
+	if err != nil && err != io.EOF {
+		return n, fmt.Errorf("copying: %w", err)
+	}
+	return n, nil
 }
 
-// Writes a blob to Google Cloud Storage if the key does not contain any data yet
-func (g *Gcs) WriteIfMissing(ctx context.Context, key string, data []byte) error {
-	key = path.Join(g.prefix, key)
-	wc := g.bucket.Object(key).If(storage.Conditions{DoesNotExist: true}).NewWriter(ctx)
+// Lists the keys of all blobs starting with the given prefix, sorted by key.
+func (l *Fs) List(ctx context.Context, prefix string) (_ []string, err error) {
+	defer annotate(&err, "fs", "List", prefix)
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	var keys []string
+	err = l.walk(ctx, prefix, func(key string, info fs.FileInfo) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
 
-	if _, err := wc.Write(data); err != nil {
-		return fmt.Errorf("writing: %w", err)
+// Lists all blobs whose key starts with the given prefix, sorted by key.
+func (l *Fs) ListInfo(ctx context.Context, prefix string) ([]BlobInfo, error) {
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	var infos []BlobInfo
+	err := l.walk(ctx, prefix, func(key string, info fs.FileInfo) error {
+		infos = append(infos, BlobInfo{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	if err := wc.Close(); err != nil {
-		if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == 412 {
-			return nil
+	return infos, nil
+}
+
+// Lists all blobs whose key starts with the given prefix and that were
+// modified after since.
+func (l *Fs) ListSince(ctx context.Context, prefix string, since time.Time) ([]BlobInfo, error) {
+	infos, err := l.ListInfo(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return modifiedSince(infos, since), nil
+}
+
+// Opens the blob at the given key, verifying and decompressing it while it is
+// read if the options ask for it.
+func (l *Fs) open(key string) (io.ReadCloser, error) {
+	path, err := l.filePath(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !l.verify && !l.autoDecompress {
+		return file, nil
+	}
+	m, err := l.readMeta(key)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	var rc io.ReadCloser = file
+	if l.verify && m.SHA256 != "" {
+		rc = &verifyingReader{ReadCloser: rc, key: key, want: m.SHA256, h: sha256.New()}
+	}
+	if l.autoDecompress {
+		if rc, err = decompress(key, rc, m.ContentEncoding); err != nil {
+			file.Close()
+			return nil, err
 		}
-		return fmt.Errorf("closing writer: %w", err)
 	}
-	return nil
+	return rc, nil
 }
 
-// Remove removes a blob from Google Cloud Storage.
-func (g *Gcs) Remove(ctx context.Context, key string) error {
-	key = path.Join(g.prefix, key)
-	err := g.bucket.Object(key).Delete(ctx)
+// Reports whether a blob exists at the given key.
+func (l *Fs) Exists(ctx context.Context, key string) (bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	path, err := l.filePath(key)
 	if err != nil {
-		return fmt.Errorf("deleting object: %w", err)
+		return false, err
 	}
-	return nil
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("stating file: %w", err)
+	}
+	return !info.IsDir(), nil
 }
 
-// Removes all objects at the specified folder (prefix)
-func (g *Gcs) RemoveFolder(ctx context.Context, folder string) error {
-	folder = path.Join(g.prefix, folder)
-	it := g.bucket.Objects(ctx, &storage.Query{Prefix: folder + "/"})
-	errG, ctx := errgroup.WithContext(ctx)
-	for {
-		objAttrs, err := it.Next()
-		if err == iterator.Done {
-			break
+// Reports whether a blob exists at the given key and was modified within
+// maxAge, such as a heartbeat that is written periodically. A stale blob
+// reports false like a missing one, without an error.
+func (l *Fs) ExistsFresh(ctx context.Context, key string, maxAge time.Duration) (bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	path, err := l.filePath(key)
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("stating file: %w", err)
+	}
+	return !info.IsDir() && time.Since(info.ModTime()) <= maxAge, nil
+}
+
+// Returns an opaque token that changes whenever the blob at the given key
+// changes, for cheap change detection between polls. It consists of the
+// modification time and size, so a rewrite with the same size within the
+// file system's timestamp resolution keeps the token.
+func (l *Fs) Version(ctx context.Context, key string) (string, error) {
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	path, err := l.filePath(key)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("stating file: %w", wrapNotFound(err))
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size()), nil
+}
+
+// Reports whether the given path is a folder, meaning a directory exists for
+// it. Together with Exists this classifies a path as blob, folder or missing.
+// Unlike FolderExists, an empty directory, such as one left by removes,
+// counts as a folder.
+func (l *Fs) IsFolder(ctx context.Context, path string) (bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	dir, err := l.filePath(path)
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("stating directory: %w", err)
+	}
+	return info.IsDir(), nil
+}
+
+// Returns the file path of a key, validating that it stays within the base
+// path, that each of its components fits within FsMaxKeyComponentLength and
+// that its name does not start with the prefix of temporary files.
+func (l *Fs) filePath(key string) (string, error) {
+	encoded := l.encodeKey(key)
+	for _, part := range strings.Split(encoded, "/") {
+		if len(part) > FsMaxKeyComponentLength {
+			return "", fmt.Errorf("%w: component %q of key %q exceeds %d bytes", ErrKeyTooLong, part, key, FsMaxKeyComponentLength)
+		}
+	}
+	if strings.HasPrefix(path.Base(encoded), fsTempPrefix) {
+		return "", fmt.Errorf("%w: key %q starts like a temporary file", ErrInvalidKey, key)
+	}
+	return l.join(key)
+}
+
+// Joins a key to the base path, failing with ErrInvalidKey if the cleaned
+// result escapes the base path, such as with ".." segments, or lies in the
+// reserved metadata folder. Keys are checked lexically: symbolic links within
+// the base path are trusted and followed.
+func (l *Fs) join(key string) (string, error) {
+	p := filepath.Join(l.basePath, filepath.FromSlash(l.encodeKey(key)))
+	rel, err := filepath.Rel(l.basePath, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: key %q escapes the base path", ErrInvalidKey, key)
+	}
+	if rel == fsMetaDir || strings.HasPrefix(rel, fsMetaDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: key %q is in the reserved %s folder", ErrInvalidKey, key, fsMetaDir)
+	}
+	return p, nil
+}
+
+// Walks all files whose key starts with the given prefix in lexical order.
+func (l *Fs) walk(ctx context.Context, prefix string, fn func(key string, info fs.FileInfo) error) error {
+	// Only the directory containing the prefix needs to be walked.
+	dir := prefix
+	if !strings.HasSuffix(dir, "/") {
+		dir = path.Dir(dir)
+	}
+	root, err := l.join(dir)
+	if err != nil {
+		return err
+	}
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil // Nothing to list
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
 		}
+		rel, err := filepath.Rel(l.basePath, p)
 		if err != nil {
-			return fmt.Errorf("iterating objects: %w", err)
+			return err
 		}
-		errG.Go(func() error {
-			err = g.bucket.Object(objAttrs.Name).Delete(ctx)
-			if err != nil {
-				return fmt.Errorf("deleting object: %w", err)
+		key := l.decodeKey(filepath.ToSlash(rel))
+		if d.IsDir() {
+			if key == fsMetaDir {
+				return filepath.SkipDir // Metadata sidecars are not blobs
+			}
+			// Skip directories that cannot contain matching keys.
+			dirKey := key + "/"
+			if p != root && !strings.HasPrefix(dirKey, prefix) && !strings.HasPrefix(prefix, dirKey) {
+				return filepath.SkipDir
 			}
 			return nil
-		})
-	}
-	if err := errG.Wait(); err != nil {
-		return fmt.Errorf("waiting for delete operations: %w", err)
+		}
+		if !strings.HasPrefix(key, prefix) || strings.HasPrefix(d.Name(), fsTempPrefix) {
+			return nil // Not matching or the temporary file of a write
+		}
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil // Removed while walking
+			}
+			return err
+		}
+		return fn(key, info)
+	})
+	if err != nil {
+		return fmt.Errorf("walking directory: %w", err)
 	}
 	return nil
 }
 
-// Returns an io readerCloser for the blob at the given key.
-func (g *Gcs) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
-	key = path.Join(g.prefix, key)
-	rc, err := g.bucket.Object(key).NewReader(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("creating reader: %w", err)
-	}
-	return rc, nil
-}
+// Maximum length in bytes of a GCS object name, including the Gcs prefix.
+const GcsMaxKeyLength = 1024
 
-// Returns an io writerCloser for the blob at the given key.
-func (g *Gcs) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
-	key = path.Join(g.prefix, key)
-	wc := g.bucket.Object(key).NewWriter(ctx)
-	if wc == nil {
-		return nil, fmt.Errorf("creating writer for key %s", key)
+// Gcs implements Storage for Google Cloud Storage.
+type Gcs struct {
+	bucket *storage.BucketHandle
+	prefix string
+
+	scanPrefix  bool         // Whether to collect prefix stats on init.
+	prefixStats *PrefixStats // Stats collected on init, if enabled.
+
+	removeProgress    func(RemoveFolderProgress) // Reports RemoveFolder progress, if set.
+	removeConcurrency int                        // Maximum concurrent deletes of RemoveFolder.
+	retry             *gcsRetry                  // Retry policy of all operations, if set.
+	client            *storage.Client            // Client of the bucket.
+	ownsClient        bool                       // Whether the client was created here and Close closes it.
+	clientOpts        []option.ClientOption      // Options of the created client.
+	emulator          bool                       // Whether the client talks to an emulator.
+	listMetadata      bool                       // Whether ListInfo includes custom metadata.
+	timeout           time.Duration              // Default timeout of operations without a deadline.
+	crc32c            bool                       // Whether to send and verify CRC32C checksums.
+	sniffer           *Sniffer                   // Detects content types of writes without one, if set.
+
+	uploadMu       sync.Mutex   // Guards uploadClient and uploadEndpoint.
+	uploadClient   *http.Client // HTTP client of StartResumableUpload, once created.
+	uploadEndpoint string       // JSON API endpoint of uploadClient.
+}
+
+// Configures a Gcs instance.
+type GcsOption interface {
+	applyGcs(*Gcs)
+}
+
+// Adapts a function to a GcsOption.
+type gcsOptionFunc func(*Gcs)
+
+func (f gcsOptionFunc) applyGcs(g *Gcs) { f(g) }
+
+// Scans all objects under the prefix when the storage is created and caches
+// their count and total size, see Gcs.PrefixStats. Scanning a large prefix is
+// expensive and slows down initialization accordingly.
+func WithPrefixStats() GcsOption {
+	return gcsOptionFunc(func(g *Gcs) {
+		g.scanPrefix = true
+	})
+}
+
+// Calls fn after every delete of a RemoveFolder with the progress so far. The
+// counters are kept in atomics, so reporting barely affects throughput, but fn
+// is called concurrently from the deleting goroutines and must be fast and
+// safe for concurrent use.
+func WithRemoveFolderProgress(fn func(RemoveFolderProgress)) GcsOption {
+	return gcsOptionFunc(func(g *Gcs) {
+		g.removeProgress = fn
+	})
+}
+
+// Default number of objects Gcs.RemoveFolder deletes concurrently.
+const DefaultRemoveFolderConcurrency = 64
+
+// Sets how many objects RemoveFolder deletes concurrently, which defaults to
+// DefaultRemoveFolderConcurrency. Higher values remove large folders faster
+// but risk rate limiting by GCS.
+func WithRemoveFolderConcurrency(n int) GcsOption {
+	return gcsOptionFunc(func(g *Gcs) {
+		g.removeConcurrency = n
+	})
+}
+
+// Progress of a running Gcs.RemoveFolder.
+type RemoveFolderProgress struct {
+	Deleted  int64         // Number of objects deleted so far.
+	InFlight int64         // Number of deletes currently running.
+	Elapsed  time.Duration // Time since the RemoveFolder started.
+}
+
+// Returns the average number of deletes per second so far.
+func (p RemoveFolderProgress) DeletesPerSecond() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Deleted) / p.Elapsed.Seconds()
+}
+
+// Includes the custom metadata of every blob in ListInfo and ListSince
+// results. GCS returns it as part of the listing, so this does not cost
+// additional requests, but larger listing responses.
+func WithListMetadata() GcsOption {
+	return gcsOptionFunc(func(g *Gcs) {
+		g.listMetadata = true
+	})
+}
+
+// Default initial backoff of WithRetry.
+const DefaultRetryBackoff = 100 * time.Millisecond
+
+// Retry policy set by WithRetry.
+type gcsRetry struct {
+	maxAttempts int
+	backoff     time.Duration
+}
+
+// Retries every operation up to maxAttempts times in total when it fails
+// with a transient error as classified by Gcs.Retryable, such as a 503 or a
+// reset connection, or until the context is done if maxAttempts is zero.
+// Errors like 404 and 412 are never retried. The backoff
+// starts at initialBackoff, DefaultRetryBackoff if zero, and doubles up to 30
+// seconds with jitter. Retries stop when the context is done.
+//
+// Unlike the client's default policy, writes without preconditions are
+// retried as well, which is safe as they overwrite. A conditional write whose
+// success response was lost is retried too and then fails with
+// ErrPreconditionFailed, although it took effect.
+func WithRetry(maxAttempts int, initialBackoff time.Duration) GcsOption {
+	return gcsOptionFunc(func(g *Gcs) {
+		if initialBackoff <= 0 {
+			initialBackoff = DefaultRetryBackoff
+		}
+		g.retry = &gcsRetry{maxAttempts: maxAttempts, backoff: initialBackoff}
+	})
+}
+
+// Passes opts to the GCS client NewGcsStorage creates, such as credentials,
+// option.WithEndpoint for an emulator or option.WithHTTPClient. With
+// WithClient they are only used by StartResumableUpload, which needs its own
+// HTTP client, so pass the options client was created with as well.
+func WithClientOptions(opts ...option.ClientOption) GcsOption {
+	return gcsOptionFunc(func(g *Gcs) {
+		g.clientOpts = append(g.clientOpts, opts...)
+	})
+}
+
+// Makes the created GCS client send its requests through client, which must
+// authenticate them itself. Only used by StartResumableUpload if WithClient is
+// given.
+func WithHTTPClient(client *http.Client) GcsOption {
+	return WithClientOptions(option.WithHTTPClient(client))
+}
+
+// Uses client instead of creating a new GCS client. Close then leaves it open.
+func WithClient(client *storage.Client) GcsOption {
+	return gcsOptionFunc(func(g *Gcs) {
+		g.client = client
+	})
+}
+
+// Returns a new Gcs blob storage instance.
+func NewGcsStorage(ctx context.Context, bucket string, prefix string, opts ...GcsOption) (*Gcs, error) {
+	g := &Gcs{prefix: prefix, emulator: emulatorHostSet()}
+	for _, opt := range opts {
+		opt.applyGcs(g)
+	}
+	if g.client == nil {
+		client, err := storage.NewClient(ctx, g.clientOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("creating client: %w", err)
+		}
+		g.client, g.ownsClient = client, true
+	}
+	g.bucket = g.client.Bucket(bucket)
+	if g.retry != nil {
+		retryOpts := []storage.RetryOption{
+			storage.WithBackoff(gax.Backoff{Initial: g.retry.backoff, Max: 30 * time.Second, Multiplier: 2}),
+			storage.WithPolicy(storage.RetryAlways),
+			storage.WithErrorFunc(g.Retryable),
+		}
+		if g.retry.maxAttempts > 0 {
+			retryOpts = append(retryOpts, storage.WithMaxAttempts(g.retry.maxAttempts))
+		}
+		g.bucket = g.bucket.Retryer(retryOpts...)
+	}
+	if g.scanPrefix {
+		stats, err := g.scanPrefixStats(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("scanning prefix: %w", err)
+		}
+		g.prefixStats = stats
+	}
+	return g, nil
+}
+
+// Returns a Gcs blob storage instance using an existing client, so that many
+// instances can share its connection pool. The caller stays responsible for
+// closing the client, Close leaves it open.
+func NewGcsStorageWithClient(client *storage.Client, bucket string, prefix string) *Gcs {
+	return &Gcs{client: client, bucket: client.Bucket(bucket), prefix: prefix, emulator: emulatorHostSet()}
+}
+
+// Closes the GCS client if NewGcsStorage created it, releasing its
+// connections, and the idle connections of StartResumableUpload. A client
+// passed in with WithClient or NewGcsStorageWithClient is shared and left
+// open.
+func (g *Gcs) Close() error {
+	g.uploadMu.Lock()
+	if g.uploadClient != nil {
+		g.uploadClient.CloseIdleConnections()
+	}
+	g.uploadMu.Unlock()
+	if !g.ownsClient {
+		return nil
+	}
+	if err := g.client.Close(); err != nil {
+		return fmt.Errorf("closing client: %w", err)
+	}
+	return nil
+}
+
+// Statistics of the objects under a Gcs prefix.
+type PrefixStats struct {
+	ObjectCount int64     // Number of objects under the prefix.
+	TotalBytes  int64     // Sum of the sizes of all objects under the prefix.
+	ScannedAt   time.Time // When the prefix was scanned.
+}
+
+// Returns the prefix stats collected on init and whether they were collected,
+// which requires the WithPrefixStats option. The stats are a point-in-time
+// snapshot and do not reflect any changes made after ScannedAt.
+func (g *Gcs) PrefixStats() (PrefixStats, bool) {
+	if g.prefixStats == nil {
+		return PrefixStats{}, false
+	}
+	return *g.prefixStats, true
+}
+
+// Lists all objects under the prefix, only fetching their sizes.
+func (g *Gcs) scanPrefixStats(ctx context.Context) (*PrefixStats, error) {
+	query := &storage.Query{}
+	if g.prefix != "" {
+		query.Prefix = g.prefix + "/"
+	}
+	if err := query.SetAttrSelection([]string{"Size"}); err != nil {
+		return nil, fmt.Errorf("selecting attributes: %w", err)
+	}
+	stats := &PrefixStats{ScannedAt: time.Now()}
+	it := g.bucket.Objects(ctx, query)
+	for {
+		objAttrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("iterating objects: %w", err)
+		}
+		stats.ObjectCount++
+		stats.TotalBytes += objAttrs.Size
+	}
+	return stats, nil
+}
+
+// Reads a blob from Google Cloud Storage.
+func (g *Gcs) Read(ctx context.Context, key string) (_ []byte, err error) {
+	defer annotate(&err, "gcs", "Read", key)
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	key, err = g.objectName(key)
+	if err != nil {
+		return nil, err
+	}
+	if g.crc32c {
+		return g.readVerified(ctx, key)
+	}
+	rc, err := g.bucket.Object(key).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating reader: %w", err)
+	}
+	defer rc.Close()
+
+	// The object size is usually known once the reader is open, which allows
+	// allocating the buffer once instead of growing it while reading. It is
+	// unknown for objects that are decompressed while downloading.
+	size := rc.Remain()
+	if size < 0 {
+		return io.ReadAll(rc)
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, size+bytes.MinRead))
+	if _, err := buf.ReadFrom(rc); err != nil {
+		return nil, fmt.Errorf("reading: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Writes a blob to Google Cloud Storage.
+func (g *Gcs) Write(ctx context.Context, key string, data []byte) (err error) {
+	defer annotate(&err, "gcs", "Write", key)
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	return g.With(Conditions{}).Write(ctx, key, data)
+}
+
+// Writes a blob to Google Cloud Storage with the given options.
+func (g *Gcs) WriteWithOptions(ctx context.Context, key string, data []byte, opts WriteOptions) error {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	return g.With(Conditions{}).WriteWithOptions(ctx, key, data, opts)
+}
+
+// Writes a blob to Google Cloud Storage if the key does not contain any data yet
+func (g *Gcs) WriteIfMissing(ctx context.Context, key string, data []byte) (err error) {
+	defer annotate(&err, "gcs", "WriteIfMissing", key)
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	_, err = g.writeIfMissing(ctx, key, data)
+	return err
+}
+
+// Writes a blob to Google Cloud Storage if the key does not contain any data
+// yet and reports whether this call created it, such as to run first-time
+// initialization only once. An existing object fails the precondition of the
+// write, which is reported as false, nil.
+func (g *Gcs) WriteIfMissingOK(ctx context.Context, key string, data []byte) (_ bool, err error) {
+	defer annotate(&err, "gcs", "WriteIfMissingOK", key)
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	return g.writeIfMissing(ctx, key, data)
+}
+
+// Writes a blob if the key does not contain any data yet and reports whether
+// it did.
+func (g *Gcs) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	err := g.With(Conditions{DoesNotExist: true}).Write(ctx, key, data)
+	if errors.Is(err, ErrPreconditionFailed) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Remove removes a blob from Google Cloud Storage.
+func (g *Gcs) Remove(ctx context.Context, key string) (err error) {
+	defer annotate(&err, "gcs", "Remove", key)
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	return g.With(Conditions{}).Remove(ctx, key)
+}
+
+// Removes all objects under the specified folder, meaning with the prefix
+// folder/, so an object named like the folder is kept. Up to
+// DefaultRemoveFolderConcurrency objects are deleted concurrently, see
+// WithRemoveFolderConcurrency. The first failed delete cancels the rest.
+func (g *Gcs) RemoveFolder(ctx context.Context, folder string) (err error) {
+	defer annotate(&err, "gcs", "RemoveFolder", folder)
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	_, err = g.removeFolder(ctx, folder)
+	return err
+}
+
+// Removes a folder like RemoveFolder and returns the number of objects
+// deleted, such as for audit logs. When a delete fails, the objects deleted
+// until then are counted.
+func (g *Gcs) RemoveFolderCount(ctx context.Context, folder string) (_ int, err error) {
+	defer annotate(&err, "gcs", "RemoveFolderCount", folder)
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	return g.removeFolder(ctx, folder)
+}
+
+// Deletes all objects under a folder concurrently and counts the deletes.
+func (g *Gcs) removeFolder(ctx context.Context, folder string) (int, error) {
+	if err := checkFolder(folder); err != nil {
+		return 0, err
+	}
+	folder, err := g.objectName(folder)
+	if err != nil {
+		return 0, err
+	}
+	it := g.bucket.Objects(ctx, &storage.Query{Prefix: folder + "/"})
+	errG, delCtx := errgroup.WithContext(ctx)
+	limit := g.removeConcurrency
+	if limit <= 0 {
+		limit = DefaultRemoveFolderConcurrency
+	}
+	errG.SetLimit(limit)
+	tracker := newRemoveTracker(g.removeProgress)
+	// Stops listing once a delete failed and cancelled the remaining ones.
+	for delCtx.Err() == nil {
+		objAttrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			errG.Wait()
+			return int(tracker.deleted.Load()), fmt.Errorf("iterating objects: %w", err)
+		}
+		errG.Go(func() error {
+			return tracker.delete(func() error {
+				if err := g.bucket.Object(objAttrs.Name).Delete(delCtx); err != nil {
+					return fmt.Errorf("deleting object: %w", wrapRetained(err))
+				}
+				return nil
+			})
+		})
+	}
+	if err := errG.Wait(); err != nil {
+		return int(tracker.deleted.Load()), fmt.Errorf("waiting for delete operations: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return int(tracker.deleted.Load()), fmt.Errorf("iterating objects: %w", err)
+	}
+	return int(tracker.deleted.Load()), nil
+}
+
+// Returns an io readerCloser for the blob at the given key.
+func (g *Gcs) Reader(ctx context.Context, key string) (_ io.ReadCloser, err error) {
+	defer annotate(&err, "gcs", "Reader", key)
+	key, err = g.objectName(key)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := g.bucket.Object(key).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating reader: %w", err)
+	}
+	return rc, nil
+}
+
+// Streams the blob at the given key from its object. The caller must close
+// the returned reader.
+func (g *Gcs) ReadStream(ctx context.Context, key string) (_ io.ReadCloser, err error) {
+	defer annotate(&err, "gcs", "ReadStream", key)
+	name, err := g.objectName(key)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := g.bucket.Object(name).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating reader: %w", wrapNotFound(err))
+	}
+	return rc, nil
+}
+
+// Returns an io writerCloser for the blob at the given key.
+func (g *Gcs) Writer(ctx context.Context, key string) (_ io.WriteCloser, err error) {
+	defer annotate(&err, "gcs", "Writer", key)
+	key, err = g.objectName(key)
+	if err != nil {
+		return nil, err
+	}
+	wc := g.bucket.Object(key).NewWriter(ctx)
+	if wc == nil {
+		return nil, fmt.Errorf("creating writer for key %s", key)
+	}
+	return g.contentTypeWriter(wc), nil
+}
+
+// Uploads the data read from r to the object at the given key. A failed copy
+// aborts the upload, so no partial object is committed.
+func (g *Gcs) WriteStream(ctx context.Context, key string, r io.Reader) (err error) {
+	defer annotate(&err, "gcs", "WriteStream", key)
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	name, err := g.objectName(key)
+	if err != nil {
+		return err
+	}
+	ctx, abort := context.WithCancel(ctx)
+	defer abort()
+	wc := g.contentTypeWriter(g.bucket.Object(name).NewWriter(ctx))
+	if _, err := io.Copy(wc, r); err != nil {
+		abort()
+		wc.Close()
+		return fmt.Errorf("copying: %w", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("closing writer: %w", wrapRetained(err))
+	}
+	return nil
+}
+
+// Streams the blob at the given key to w and returns the number of bytes written.
+func (g *Gcs) ReadTo(ctx context.Context, key string, w io.Writer) (int64, error) {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	key, err := g.objectName(key)
+	if err != nil {
+		return 0, err
+	}
+	rc, err := g.bucket.Object(key).NewReader(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("creating reader: %w", wrapNotFound(err))
+	}
+	defer rc.Close()
+
+	n, err := io.Copy(w, rc)
+	if err != nil {
+		return n, fmt.Errorf("copying: %w", err)
+	}
+	return n, nil
+}
+
+// Streams length bytes of the blob at the given key, starting at offset, to w
+// and returns the number of bytes written. A negative length streams until the
+// end of the blob. Fewer bytes are streamed if the blob ends before the range.
+func (g *Gcs) ReadRangeTo(ctx context.Context, key string, offset, length int64, w io.Writer) (int64, error) {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	key, err := g.objectName(key)
+	if err != nil {
+		return 0, err
+	}
+	rc, err := g.bucket.Object(key).NewRangeReader(ctx, offset, length)
+	if statusCode(err) == http.StatusRequestedRangeNotSatisfiable {
+		return 0, nil // The blob ends before the range
+	}
+	if err != nil {
+		return 0, fmt.Errorf("creating range reader: %w", wrapNotFound(err))
+	}
+	defer rc.Close()
+
+	n, err := io.Copy(w, rc)
+	if err != nil {
+		return n, fmt.Errorf("copying: %w", err)
+	}
+	return n, nil
+}
+
+// Lists the keys of all blobs starting with the given prefix, sorted by key.
+func (g *Gcs) List(ctx context.Context, prefix string) (_ []string, err error) {
+	defer annotate(&err, "gcs", "List", prefix)
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	query := &storage.Query{Prefix: g.fullPrefix(prefix)}
+	if err := query.SetAttrSelection([]string{"Name"}); err != nil {
+		return nil, fmt.Errorf("selecting attributes: %w", err)
+	}
+	var keys []string
+	it := g.bucket.Objects(ctx, query)
+	for {
+		objAttrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("iterating objects: %w", err)
+		}
+		keys = append(keys, g.relKey(objAttrs.Name))
+	}
+	return keys, nil
+}
+
+// Lists all blobs whose key starts with the given prefix, sorted by key.
+func (g *Gcs) ListInfo(ctx context.Context, prefix string) ([]BlobInfo, error) {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	query := &storage.Query{Prefix: g.fullPrefix(prefix)}
+	attrs := []string{"Name", "Size", "Updated"}
+	if g.listMetadata {
+		attrs = append(attrs, "Metadata")
+	}
+	if err := query.SetAttrSelection(attrs); err != nil {
+		return nil, fmt.Errorf("selecting attributes: %w", err)
+	}
+	var infos []BlobInfo
+	it := g.bucket.Objects(ctx, query)
+	for {
+		objAttrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("iterating objects: %w", err)
+		}
+		infos = append(infos, BlobInfo{
+			Key:      g.relKey(objAttrs.Name),
+			Size:     objAttrs.Size,
+			ModTime:  objAttrs.Updated,
+			Metadata: objAttrs.Metadata,
+		})
+	}
+	return infos, nil
+}
+
+// Lists all blobs whose key starts with the given prefix and that were
+// updated after since. GCS cannot filter by update time on the server, so
+// this still iterates over every object under the prefix.
+func (g *Gcs) ListSince(ctx context.Context, prefix string, since time.Time) ([]BlobInfo, error) {
+	infos, err := g.ListInfo(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return modifiedSince(infos, since), nil
+}
+
+// Reports whether a blob exists at the given key.
+func (g *Gcs) Exists(ctx context.Context, key string) (bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	key, err := g.objectName(key)
+	if err != nil {
+		return false, err
+	}
+	_, err = g.bucket.Object(key).Attrs(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("getting attributes: %w", err)
+	}
+	return true, nil
+}
+
+// Reports whether a blob exists at the given key and was updated within
+// maxAge, such as a heartbeat that is written periodically. A stale blob
+// reports false like a missing one, without an error.
+func (g *Gcs) ExistsFresh(ctx context.Context, key string, maxAge time.Duration) (bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	key, err := g.objectName(key)
+	if err != nil {
+		return false, err
+	}
+	attrs, err := g.bucket.Object(key).Attrs(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("getting attributes: %w", err)
+	}
+	return time.Since(attrs.Updated) <= maxAge, nil
+}
+
+// Returns an opaque token that changes whenever the blob at the given key
+// changes, for cheap change detection between polls. It is the object
+// generation, which increases with every write.
+func (g *Gcs) Version(ctx context.Context, key string) (string, error) {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	key, err := g.objectName(key)
+	if err != nil {
+		return "", err
+	}
+	attrs, err := g.bucket.Object(key).Attrs(ctx)
+	if err != nil {
+		return "", fmt.Errorf("getting attributes: %w", wrapNotFound(err))
+	}
+	return strconv.FormatInt(attrs.Generation, 10), nil
+}
+
+// Reports whether the given path is a folder, meaning at least one blob exists
+// under path+"/". Together with Exists this classifies a path as blob, folder
+// or missing. GCS has no empty folders, so this is the same as FolderExists.
+func (g *Gcs) IsFolder(ctx context.Context, path string) (bool, error) {
+	return g.FolderExists(ctx, path)
+}
+
+// Returns the object name of a key, validating that it fits within
+// GcsMaxKeyLength.
+func (g *Gcs) objectName(key string) (string, error) {
+	name := path.Join(g.prefix, key)
+	if len(name) > GcsMaxKeyLength {
+		return "", fmt.Errorf("%w: object name for key %q exceeds %d bytes", ErrKeyTooLong, key, GcsMaxKeyLength)
+	}
+	return name, nil
+}
+
+// Returns the object name prefix for a key prefix.
+func (g *Gcs) fullPrefix(prefix string) string {
+	if g.prefix == "" {
+		return prefix
+	}
+	return g.prefix + "/" + prefix
+}
+
+// Returns the key of an object name, relative to the storage prefix.
+func (g *Gcs) relKey(name string) string {
+	if g.prefix == "" {
+		return name
+	}
+	return strings.TrimPrefix(name, g.prefix+"/")
+}
+
+// Options for rewriting an object in place.
+type RewriteOptions struct {
+	// Storage class to move the object to, e.g. "NEARLINE". Empty keeps the
+	// current storage class.
+	StorageClass string
+	// Cloud KMS key to re-encrypt the object with, in the form
+	// projects/P/locations/L/keyRings/R/cryptoKeys/K. Empty keeps the current
+	// encryption.
+	KMSKeyName string
+	// Called after each rewrite call of a multi-call rewrite with the bytes
+	// copied so far and the total size of the object.
+	ProgressFunc func(copiedBytes, totalBytes uint64)
+}
+
+// Rewrites the blob at the given key in place, e.g. to change its storage
+// class or encryption key, without downloading it. The content type and
+// custom metadata are preserved. Large objects may need multiple rewrite
+// calls, which the copier performs by passing on the rewrite token until the
+// rewrite is done. The rewrite fails if the object changes in the meantime.
+func (g *Gcs) Rewrite(ctx context.Context, key string, opts RewriteOptions) error {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	if opts.KMSKeyName != "" {
+		if err := g.requireRealGcs("rewriting with a KMS key"); err != nil {
+			return err
+		}
+	}
+	key, err := g.objectName(key)
+	if err != nil {
+		return err
+	}
+	obj := g.bucket.Object(key)
+	attrs, err := obj.Attrs(ctx)
+	if err != nil {
+		return fmt.Errorf("getting attributes: %w", wrapNotFound(err))
+	}
+
+	dst := obj.If(storage.Conditions{GenerationMatch: attrs.Generation})
+	copier := dst.CopierFrom(obj.Generation(attrs.Generation))
+	copier.ContentType = attrs.ContentType
+	copier.ContentEncoding = attrs.ContentEncoding
+	copier.ContentLanguage = attrs.ContentLanguage
+	copier.ContentDisposition = attrs.ContentDisposition
+	copier.CacheControl = attrs.CacheControl
+	copier.Metadata = attrs.Metadata
+	copier.StorageClass = opts.StorageClass
+	copier.DestinationKMSKeyName = opts.KMSKeyName
+	copier.ProgressFunc = opts.ProgressFunc
+	if _, err := copier.Run(ctx); err != nil {
+		return fmt.Errorf("rewriting object: %w", wrapPreconditionFailed(err))
+	}
+	return nil
+}
+
+// Controls how UpdateMetadata and CopyWithOptions apply the given metadata.
+type MetadataMode int
+
+const (
+	// Sets the given metadata keys and leaves all other keys unchanged.
+	MetadataMerge MetadataMode = iota
+	// Sets the given metadata and removes all keys that are not given.
+	MetadataReplace
+	// Keeps the current metadata and ignores the given metadata.
+	MetadataPreserve
+)
+
+// Updates the custom metadata of the blob at the given key according to mode.
+// The current metadata is read first and updated with a metageneration
+// precondition, so concurrent metadata updates fail instead of being lost.
+//
+// GCS cannot remove individual metadata keys, so replacing metadata that
+// drops existing keys takes two updates: one clearing all metadata and one
+// setting the new metadata.
+func (g *Gcs) UpdateMetadata(ctx context.Context, key string, metadata map[string]string, mode MetadataMode) error {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	key, err := g.objectName(key)
+	if err != nil {
+		return err
+	}
+	obj := g.bucket.Object(key)
+	attrs, err := obj.Attrs(ctx)
+	if err != nil {
+		return fmt.Errorf("getting attributes: %w", wrapNotFound(err))
+	}
+
+	update := metadata
+	switch mode {
+	case MetadataMerge:
+		update = maps.Clone(attrs.Metadata)
+		if update == nil {
+			update = make(map[string]string, len(metadata))
+		}
+		maps.Copy(update, metadata)
+	case MetadataReplace:
+		removesKeys := false
+		for k := range attrs.Metadata {
+			if _, ok := metadata[k]; !ok {
+				removesKeys = true
+				break
+			}
+		}
+		if removesKeys {
+			attrs, err = obj.If(storage.Conditions{MetagenerationMatch: attrs.Metageneration}).
+				Update(ctx, storage.ObjectAttrsToUpdate{Metadata: map[string]string{}})
+			if err != nil {
+				return fmt.Errorf("clearing metadata: %w", wrapPreconditionFailed(err))
+			}
+		}
+	case MetadataPreserve:
+		return nil
+	default:
+		return fmt.Errorf("unknown metadata mode %d", mode)
+	}
+	if len(update) == 0 {
+		return nil
+	}
+
+	_, err = obj.If(storage.Conditions{MetagenerationMatch: attrs.Metageneration}).
+		Update(ctx, storage.ObjectAttrsToUpdate{Metadata: update})
+	if err != nil {
+		return fmt.Errorf("updating metadata: %w", wrapPreconditionFailed(err))
+	}
+	return nil
+}
+
+// Options for starting a resumable upload session.
+type ResumableUploadOptions struct {
+	// Content type of the object to create. The client completing the upload
+	// does not need to repeat it.
+	ContentType string
+	// Origin of the browser that will complete the upload, e.g.
+	// "https://app.example.com". Required for browser uploads, since GCS only
+	// returns CORS headers on the session URL to the origin that started it.
+	Origin string
+}
+
+// Starts a resumable upload session for the given key and returns its session
+// URL, so a client can upload the data directly to Google Cloud Storage.
+//
+// The client uploads by PUTting the data (or chunks of it with Content-Range
+// headers) to the returned URL. The URL acts as a credential and is valid for
+// one week. For browser uploads, opts.Origin must match the page's origin and
+// the bucket's CORS configuration must allow PUT requests from that origin.
+//
+// The session is started with the options of WithClientOptions, so with the
+// same credentials and endpoint as the GCS client. A storage created with
+// NewGcsStorageWithClient has none and uses the default credentials and
+// endpoint.
+func (g *Gcs) StartResumableUpload(ctx context.Context, key string, opts ResumableUploadOptions) (string, error) {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	if err := g.requireRealGcs("starting a resumable upload"); err != nil {
+		return "", err
+	}
+	key, err := g.objectName(key)
+	if err != nil {
+		return "", err
+	}
+	client, endpoint, err := g.httpClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	u, err := uploadURL(endpoint, g.bucket.BucketName(), key)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := json.Marshal(map[string]string{"name": key, "contentType": opts.ContentType})
+	if err != nil {
+		return "", fmt.Errorf("encoding object metadata: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	if opts.ContentType != "" {
+		req.Header.Set("X-Upload-Content-Type", opts.ContentType)
+	}
+	if opts.Origin != "" {
+		req.Header.Set("Origin", opts.Origin)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("starting upload session: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("starting upload session: %s: %s", resp.Status, msg)
+	}
+	sessionURL := resp.Header.Get("Location")
+	if sessionURL == "" {
+		return "", fmt.Errorf("starting upload session: missing Location header")
+	}
+	return sessionURL, nil
+}
+
+// Returns the HTTP client of StartResumableUpload and its endpoint, creating
+// them on first use. The storage client does not expose its HTTP client, so
+// one is created from the same options, with the same credentials and
+// endpoint. A failed creation is retried on the next call.
+func (g *Gcs) httpClient(ctx context.Context) (*http.Client, string, error) {
+	g.uploadMu.Lock()
+	defer g.uploadMu.Unlock()
+	if g.uploadClient == nil {
+		clientOpts := append([]option.ClientOption{option.WithScopes(storage.ScopeReadWrite)}, g.clientOpts...)
+		client, endpoint, err := htransport.NewClient(ctx, clientOpts...)
+		if err != nil {
+			return nil, "", fmt.Errorf("creating http client: %w", err)
+		}
+		g.uploadClient, g.uploadEndpoint = client, endpoint
+	}
+	return g.uploadClient, g.uploadEndpoint, nil
+}
+
+// Default JSON API endpoint of GCS, used unless option.WithEndpoint is given.
+const gcsDefaultEndpoint = "https://storage.googleapis.com/storage/v1/"
+
+// Returns the URL starting a resumable upload of the named object, derived
+// from the JSON API endpoint, such as https://storage.googleapis.com/storage/v1/.
+func uploadURL(endpoint, bucket, name string) (string, error) {
+	if endpoint == "" {
+		endpoint = gcsDefaultEndpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("parsing endpoint: %w", err)
+	}
+	base := strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/storage/v1")
+	return fmt.Sprintf("%s://%s%s/upload/storage/v1/b/%s/o?uploadType=resumable&name=%s",
+		u.Scheme, u.Host, base, url.PathEscape(bucket), url.QueryEscape(name)), nil
+}
+
+// Returns the blobs modified after since.
+func modifiedSince(infos []BlobInfo, since time.Time) []BlobInfo {
+	var modified []BlobInfo
+	for _, info := range infos {
+		if info.ModTime.After(since) {
+			modified = append(modified, info)
+		}
 	}
-	return wc, nil
+	return modified
 }
 
 // Ensure that our types satisfy the interface
 var (
 	_ Storage = &Fs{}
 	_ Storage = &Gcs{}
+	_ Storage = &Redis{}
+	_ Storage = &HTTP{}
+	_ Storage = &Mem{}
 )
@@ -1,13 +1,22 @@
 package blob_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"cloud.google.com/go/storage"
 	"github.com/acudac-com/blob-go"
+	"google.golang.org/api/option"
 )
 
 func TestLocalFiles(t *testing.T) {
@@ -106,3 +115,717 @@ func TestGcsBucket_RemoveFolder(t *testing.T) {
 		t.Fatalf("Remove folder failed: %v", err)
 	}
 }
+
+// Answers every request with 404 Not Found and counts them.
+type notFoundTransport struct {
+	requests atomic.Int64
+}
+
+func (t *notFoundTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return &http.Response{
+		StatusCode: http.StatusNotFound,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"error":{"code":404,"message":"Not Found"}}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestGcsBucket_HTTPClient(t *testing.T) {
+	ctx := context.Background()
+	transport := &notFoundTransport{}
+	gcs, err := blob.NewGcsStorage(ctx, "test-bucket", "", blob.WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		t.Fatalf("NewGcsStorage failed: %v", err)
+	}
+	exists, err := gcs.Exists(ctx, "missing.txt")
+	if err != nil {
+		t.Fatalf("Exists failed: %v", err)
+	}
+	if exists || transport.requests.Load() == 0 {
+		t.Fatalf("Expected a missing blob through the custom http client, got exists %v after %d requests", exists, transport.requests.Load())
+	}
+}
+
+func TestGcsBucket_SharedClient(t *testing.T) {
+	ctx := context.Background()
+	transport := &notFoundTransport{}
+	client, err := storage.NewClient(ctx, option.WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	first := blob.NewGcsStorageWithClient(client, "test-bucket", "a")
+	second := blob.NewGcsStorageWithClient(client, "test-bucket", "b")
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	// The shared client stays usable after closing one of its storages.
+	if exists, err := second.Exists(ctx, "missing.txt"); err != nil || exists {
+		t.Fatalf("Exists on shared client = %v, %v", exists, err)
+	}
+}
+
+func BenchmarkGcsBucket_Read(b *testing.B) {
+	ctx := context.Background()
+	gcs, err := blob.NewGcsStorage(ctx, os.Getenv("GCS_BUCKET"), "someprefix/sub")
+	if err != nil {
+		b.Fatal(err)
+	}
+	key := "bench/large_object.bin"
+	data := make([]byte, 8<<20)
+	if err := gcs.Write(ctx, key, data); err != nil {
+		b.Fatalf("Write failed: %v", err)
+	}
+	defer gcs.Remove(ctx, key)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		if _, err := gcs.Read(ctx, key); err != nil {
+			b.Fatalf("Read failed: %v", err)
+		}
+	}
+}
+
+func TestLocalFiles_AtomicWrite(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_atomic_write"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	versions := [][]byte{bytes.Repeat([]byte("a"), 1<<20), bytes.Repeat([]byte("b"), 1<<20)}
+	if err := localFS.Write(ctx, "state.bin", versions[0]); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		defer close(done)
+		for i := range 20 {
+			if err := localFS.Write(ctx, "state.bin", versions[i%2]); err != nil {
+				done <- err
+				return
+			}
+		}
+	}()
+	for reading := true; reading; {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			reading = false
+		default:
+		}
+		data, err := localFS.Read(ctx, "state.bin")
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		if !bytes.Equal(data, versions[0]) && !bytes.Equal(data, versions[1]) {
+			t.Fatalf("Read a partial write of %d bytes", len(data))
+		}
+	}
+	if info, err := os.Stat(basePath + "/state.bin"); err != nil || info.Mode().Perm() != 0o644 {
+		t.Fatalf("Expected file mode 0644, got %v, %v", info, err)
+	}
+}
+
+func TestLocalFiles_RemoveFolderCount(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_remove_folder_count"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	for _, key := range []string{"tenants/1/a", "tenants/1/b/c", "tenants/1/b/d", "tenants/2/a"} {
+		if err := localFS.Write(ctx, key, []byte("data")); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	n, err := localFS.RemoveFolderCount(ctx, "tenants/1")
+	if err != nil {
+		t.Fatalf("RemoveFolderCount failed: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("RemoveFolderCount = %d, want 3", n)
+	}
+	keys, err := localFS.List(ctx, "tenants/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if !reflect.DeepEqual(keys, []string{"tenants/2/a"}) {
+		t.Fatalf("List after RemoveFolderCount = %v", keys)
+	}
+	if n, err := localFS.RemoveFolderCount(ctx, "tenants/1"); err != nil || n != 0 {
+		t.Fatalf("RemoveFolderCount of a removed folder = %d, %v", n, err)
+	}
+}
+
+func TestLocalFiles_RemoveFolderKeepsSameNamedBlob(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_remove_folder_same_name"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := localFS.WriteWithOptions(ctx, "users/123", []byte("data"), blob.WriteOptions{ContentType: "text/plain"}); err != nil {
+		t.Fatalf("WriteWithOptions failed: %v", err)
+	}
+	if err := localFS.Write(ctx, "users/1234/profile", []byte("data")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := localFS.RemoveFolder(ctx, "users/123"); err != nil {
+		t.Fatalf("RemoveFolder failed: %v", err)
+	}
+	if err := localFS.RemoveFolder(ctx, "users/12"); err != nil {
+		t.Fatalf("RemoveFolder failed: %v", err)
+	}
+	keys, err := localFS.List(ctx, "users/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if !reflect.DeepEqual(keys, []string{"users/123", "users/1234/profile"}) {
+		t.Fatalf("RemoveFolder removed a blob outside of the folder, left %v", keys)
+	}
+	// The metadata of the same-named blob is kept as well.
+	if info, err := localFS.Stat(ctx, "users/123"); err != nil || info.ContentType != "text/plain" {
+		t.Fatalf("Stat = %+v, %v", info, err)
+	}
+}
+
+func TestRemoveFolder_RefusesRoot(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_remove_folder_refuses_root"
+	defer os.RemoveAll(basePath)
+
+	storages := map[string]blob.Storage{"fs": blob.NewFsStorage(basePath), "mem": blob.NewMemStorage()}
+	for name, s := range storages {
+		if err := s.Write(ctx, "keep", []byte("data")); err != nil {
+			t.Fatalf("%s: Write failed: %v", name, err)
+		}
+		for _, folder := range []string{"", "/", ".", "./"} {
+			if err := s.RemoveFolder(ctx, folder); !errors.Is(err, blob.ErrInvalidKey) {
+				t.Fatalf("%s: RemoveFolder(%q) should return ErrInvalidKey, got: %v", name, folder, err)
+			}
+		}
+		if _, err := s.Read(ctx, "keep"); err != nil {
+			t.Fatalf("%s: Blob was removed by a refused RemoveFolder: %v", name, err)
+		}
+	}
+}
+
+func TestLocalFiles_ReadTo(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_read_to"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	key := "downloads/file.txt"
+	data := []byte("Hello, ReadTo!")
+	if err := localFS.Write(ctx, key, data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	n, err := localFS.ReadTo(ctx, key, &buf)
+	if err != nil {
+		t.Fatalf("ReadTo failed: %v", err)
+	}
+	if n != int64(len(data)) || !bytes.Equal(buf.Bytes(), data) {
+		t.Fatalf("ReadTo data does not match written data. Expected: %v, Got: %v", data, buf.Bytes())
+	}
+
+	_, err = localFS.ReadTo(ctx, "downloads/missing.txt", &buf)
+	if !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("ReadTo of missing key should return ErrNotFound, got: %v", err)
+	}
+}
+
+func TestLocalFiles_ListSince(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_list_since"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	for _, key := range []string{"users/1/old.txt", "users/12/a.txt", "users/2/b.txt"} {
+		if err := localFS.Write(ctx, key, []byte(key)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(basePath+"/users/1/old.txt", old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := localFS.ListSince(ctx, "users/1", time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("ListSince failed: %v", err)
+	}
+	if len(infos) != 1 || infos[0].Key != "users/12/a.txt" || infos[0].Size != int64(len("users/12/a.txt")) {
+		t.Fatalf("Unexpected blobs: %+v", infos)
+	}
+}
+
+func TestLocalFiles_KeyTooLong(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_key_too_long"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	key := "uploads/" + strings.Repeat("a", blob.FsMaxKeyComponentLength+1) + ".png"
+	err := localFS.Write(ctx, key, []byte("data"))
+	if !errors.Is(err, blob.ErrKeyTooLong) {
+		t.Fatalf("Write with too long key should return ErrKeyTooLong, got: %v", err)
+	}
+}
+
+func TestLocalFiles_PathTraversal(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_path_traversal/base"
+	defer os.RemoveAll("test_local_files_path_traversal")
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := os.MkdirAll(basePath, 0o755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile("test_local_files_path_traversal/secret", []byte("secret"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	for _, key := range []string{"../secret", "a/../../secret", "./../secret", "a/b/../../..", ".blob-meta/x", "a/../.blob-meta/x"} {
+		if _, err := localFS.Read(ctx, key); !errors.Is(err, blob.ErrInvalidKey) {
+			t.Fatalf("Read(%q) should return ErrInvalidKey, got: %v", key, err)
+		}
+		if err := localFS.Write(ctx, key, []byte("overwritten")); !errors.Is(err, blob.ErrInvalidKey) {
+			t.Fatalf("Write(%q) should return ErrInvalidKey, got: %v", key, err)
+		}
+		if err := localFS.Remove(ctx, key); !errors.Is(err, blob.ErrInvalidKey) {
+			t.Fatalf("Remove(%q) should return ErrInvalidKey, got: %v", key, err)
+		}
+		if _, err := localFS.Exists(ctx, key); !errors.Is(err, blob.ErrInvalidKey) {
+			t.Fatalf("Exists(%q) should return ErrInvalidKey, got: %v", key, err)
+		}
+	}
+	if _, err := localFS.List(ctx, "../"); !errors.Is(err, blob.ErrInvalidKey) {
+		t.Fatalf("List of a parent prefix should return ErrInvalidKey, got: %v", err)
+	}
+	if err := localFS.RemoveFolder(ctx, ".."); !errors.Is(err, blob.ErrInvalidKey) {
+		t.Fatalf("RemoveFolder of the parent should return ErrInvalidKey, got: %v", err)
+	}
+	if data, err := os.ReadFile("test_local_files_path_traversal/secret"); err != nil || string(data) != "secret" {
+		t.Fatalf("File outside of the base path was modified: %q, %v", data, err)
+	}
+
+	// Absolute keys and harmless dot segments stay within the base path.
+	for _, key := range []string{"/etc/passwd", "a/./b/../c"} {
+		if err := localFS.Write(ctx, key, []byte("data")); err != nil {
+			t.Fatalf("Write(%q) failed: %v", key, err)
+		}
+	}
+	for _, file := range []string{"etc/passwd", "a/c"} {
+		if _, err := os.Stat(basePath + "/" + file); err != nil {
+			t.Fatalf("Expected %s within the base path: %v", file, err)
+		}
+	}
+}
+
+func TestLocalFiles_ReadRangeTo(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_read_range_to"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	key := "media/video.bin"
+	if err := localFS.Write(ctx, key, []byte("0123456789")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	tests := []struct {
+		offset, length int64
+		want           string
+	}{
+		{2, 3, "234"},
+		{7, -1, "789"},
+		{8, 10, "89"},
+		{20, 5, ""},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		n, err := localFS.ReadRangeTo(ctx, key, tt.offset, tt.length, &buf)
+		if err != nil {
+			t.Fatalf("ReadRangeTo(%d, %d) failed: %v", tt.offset, tt.length, err)
+		}
+		if buf.String() != tt.want || n != int64(len(tt.want)) {
+			t.Fatalf("ReadRangeTo(%d, %d) = %q, want %q", tt.offset, tt.length, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestLocalFiles_ExistsIsFolder(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_exists_is_folder"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := localFS.Write(ctx, "docs/readme.txt", []byte("hi")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	tests := []struct {
+		path           string
+		exists, folder bool
+	}{
+		{"docs/readme.txt", true, false},
+		{"docs", false, true},
+		{"missing", false, false},
+	}
+	for _, tt := range tests {
+		exists, err := localFS.Exists(ctx, tt.path)
+		if err != nil {
+			t.Fatalf("Exists(%q) failed: %v", tt.path, err)
+		}
+		folder, err := localFS.IsFolder(ctx, tt.path)
+		if err != nil {
+			t.Fatalf("IsFolder(%q) failed: %v", tt.path, err)
+		}
+		if exists != tt.exists || folder != tt.folder {
+			t.Fatalf("%q: exists %v, folder %v, want %v, %v", tt.path, exists, folder, tt.exists, tt.folder)
+		}
+	}
+}
+
+func TestLocalFiles_ExistsFresh(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_exists_fresh"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := localFS.Write(ctx, "heartbeat", []byte("ok")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := localFS.Write(ctx, "stale", []byte("ok")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(basePath+"/stale", old, old); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+
+	tests := []struct {
+		key   string
+		fresh bool
+	}{
+		{"heartbeat", true},
+		{"stale", false},
+		{"missing", false},
+	}
+	for _, tt := range tests {
+		fresh, err := localFS.ExistsFresh(ctx, tt.key, time.Minute)
+		if err != nil {
+			t.Fatalf("ExistsFresh(%q) failed: %v", tt.key, err)
+		}
+		if fresh != tt.fresh {
+			t.Fatalf("ExistsFresh(%q) = %v, want %v", tt.key, fresh, tt.fresh)
+		}
+	}
+}
+
+func TestLocalFiles_RemoveIfEmpty(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_remove_if_empty"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := localFS.Write(ctx, "dir/placeholder", nil); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := localFS.Write(ctx, "dir/content", []byte("keep")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	tests := []struct {
+		key     string
+		removed bool
+	}{
+		{"dir/placeholder", true},
+		{"dir/content", false},
+		{"dir/missing", false},
+	}
+	for _, tt := range tests {
+		removed, err := localFS.RemoveIfEmpty(ctx, tt.key)
+		if err != nil {
+			t.Fatalf("RemoveIfEmpty(%q) failed: %v", tt.key, err)
+		}
+		if removed != tt.removed {
+			t.Fatalf("RemoveIfEmpty(%q) = %v, want %v", tt.key, removed, tt.removed)
+		}
+	}
+	if exists, _ := localFS.Exists(ctx, "dir/content"); !exists {
+		t.Fatalf("Non-empty blob was removed")
+	}
+}
+
+func TestLocalFiles_Version(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_version"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	key := "ui/state.json"
+	if err := localFS.Write(ctx, key, []byte("{}")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	v1, err := localFS.Version(ctx, key)
+	if err != nil {
+		t.Fatalf("Version failed: %v", err)
+	}
+	if again, _ := localFS.Version(ctx, key); again != v1 {
+		t.Fatalf("Version changed without a write: %s != %s", again, v1)
+	}
+	if err := localFS.Write(ctx, key, []byte(`{"open":true}`)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if v2, _ := localFS.Version(ctx, key); v2 == v1 {
+		t.Fatalf("Version did not change after a write: %s", v2)
+	}
+	if _, err := localFS.Version(ctx, "ui/missing.json"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Version of missing key should return ErrNotFound, got: %v", err)
+	}
+}
+
+func TestLocalFiles_ReadStream(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_read_stream"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	key := "videos/segment-1.ts"
+	content := bytes.Repeat([]byte("segment"), 1000)
+	if err := localFS.Write(ctx, key, content); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	rc, err := localFS.ReadStream(ctx, key)
+	if err != nil {
+		t.Fatalf("ReadStream failed: %v", err)
+	}
+	defer rc.Close()
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, rc); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), content) {
+		t.Fatalf("Expected %d streamed bytes, got %d", len(content), buf.Len())
+	}
+	if _, err := localFS.ReadStream(ctx, "videos/missing.ts"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("ReadStream of missing key should return ErrNotFound, got: %v", err)
+	}
+}
+
+// Fails after returning the first n bytes of data.
+type failingReader struct {
+	data []byte
+	n    int
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.n == 0 {
+		return 0, errors.New("connection reset")
+	}
+	n := copy(p, r.data[:min(r.n, len(r.data))])
+	r.data, r.n = r.data[n:], r.n-n
+	return n, nil
+}
+
+func TestLocalFiles_WriteStream(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_write_stream"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath, blob.WithChecksums(), blob.WithVerifyChecksums())
+	key := "uploads/video.mp4"
+	content := bytes.Repeat([]byte("frame"), 1000)
+	if err := localFS.WriteStream(ctx, key, bytes.NewReader(content)); err != nil {
+		t.Fatalf("WriteStream failed: %v", err)
+	}
+	got, err := localFS.Read(ctx, key)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("Expected %d bytes, got %d", len(content), len(got))
+	}
+
+	// A failed stream neither replaces the blob nor leaves a partial one.
+	if err := localFS.WriteStream(ctx, key, &failingReader{data: []byte("partial"), n: 3}); err == nil {
+		t.Fatal("WriteStream with a failing reader should fail")
+	}
+	if err := localFS.WriteStream(ctx, "uploads/other.mp4", &failingReader{data: []byte("partial"), n: 3}); err == nil {
+		t.Fatal("WriteStream with a failing reader should fail")
+	}
+	if got, _ := localFS.Read(ctx, key); !bytes.Equal(got, content) {
+		t.Fatalf("Failed WriteStream changed the blob to %q", got)
+	}
+	keys, err := localFS.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if !reflect.DeepEqual(keys, []string{key}) {
+		t.Fatalf("Expected only %s after failed writes, got %v", key, keys)
+	}
+
+	// The temporary file of a write in progress lies next to the blob's file
+	// and is not listed.
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- localFS.WriteStream(ctx, "uploads/live.mp4", pr) }()
+	if _, err := pw.Write([]byte("frame")); err != nil {
+		t.Fatalf("Write to pipe failed: %v", err)
+	}
+	entries, err := os.ReadDir(basePath + "/uploads")
+	if err != nil || len(entries) != 2 || !strings.HasPrefix(entries[0].Name(), ".upload-") {
+		t.Fatalf("Expected a temporary file next to %s, got %v, %v", key, entries, err)
+	}
+	if keys, _ := localFS.List(ctx, "uploads/"); !reflect.DeepEqual(keys, []string{key}) {
+		t.Fatalf("Expected only %s while writing, got %v", key, keys)
+	}
+	if _, files, _, _ := localFS.ListDirPage(ctx, "uploads/", "", 0); !reflect.DeepEqual(files, []string{key}) {
+		t.Fatalf("Expected only %s in the page while writing, got %v", key, files)
+	}
+	pw.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("WriteStream failed: %v", err)
+	}
+	if err := localFS.Write(ctx, "uploads/.upload-1", []byte("x")); !errors.Is(err, blob.ErrInvalidKey) {
+		t.Fatalf("Write to a temporary file name should return ErrInvalidKey, got: %v", err)
+	}
+}
+
+func TestLocalFiles_ErrNotFound(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_err_not_found"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	key := "missing/blob.txt"
+	if _, err := localFS.Read(ctx, key); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("Read of missing key should return ErrNotFound, got: %v", err)
+	}
+	if _, err := localFS.Stat(ctx, key); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("Stat of missing key should return ErrNotFound, got: %v", err)
+	}
+	if err := localFS.Remove(ctx, key); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("Remove of missing key should return ErrNotFound, got: %v", err)
+	}
+	if _, err := localFS.Reader(ctx, key); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("Reader of missing key should return ErrNotFound, got: %v", err)
+	}
+}
+
+func TestGcsBucket_PrefixStats(t *testing.T) {
+	fake := newFakeGcs(t, "test-bucket")
+	fake.put("app/a.txt", []byte("12345"), nil)
+	fake.put("app/sub/b.txt", []byte("123"), nil)
+	fake.put("other/c.txt", []byte("1234567"), nil)
+
+	stats, ok := fake.storage(t, "app", blob.WithPrefixStats()).PrefixStats()
+	if !ok || stats.ObjectCount != 2 || stats.TotalBytes != 8 || stats.ScannedAt.IsZero() {
+		t.Fatalf("Unexpected prefix stats %+v, %v", stats, ok)
+	}
+	// The stats are a snapshot of the time of creation.
+	gcs := fake.storage(t, "app", blob.WithPrefixStats())
+	fake.put("app/d.txt", []byte("1"), nil)
+	if stats, _ := gcs.PrefixStats(); stats.ObjectCount != 2 {
+		t.Fatalf("Expected the stats of the initial scan, got %+v", stats)
+	}
+	if _, ok := fake.storage(t, "app").PrefixStats(); ok {
+		t.Fatalf("Expected no prefix stats without WithPrefixStats")
+	}
+}
+
+func TestGcsBucket_Rewrite(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGcs(t, "test-bucket")
+	original := fake.put("data.bin", []byte("payload"), map[string]string{"owner": "alice"})
+	gcs := fake.storage(t, "")
+
+	var progress int
+	kmsKey := "projects/p/locations/l/keyRings/r/cryptoKeys/k"
+	err := gcs.Rewrite(ctx, "data.bin", blob.RewriteOptions{
+		StorageClass: "NEARLINE",
+		KMSKeyName:   kmsKey,
+		ProgressFunc: func(copied, total uint64) { progress++ },
+	})
+	if err != nil {
+		t.Fatalf("Rewrite failed: %v", err)
+	}
+	// The fake needs two calls, so the rewrite token must be followed.
+	if calls := fake.count("POST rewrite"); calls != 2 || progress == 0 {
+		t.Fatalf("Expected 2 rewrite calls with progress, got %d calls and %d progress reports", calls, progress)
+	}
+	obj := fake.object("data.bin")
+	if obj.storageClass != "NEARLINE" || obj.kmsKeyName != kmsKey || obj.generation == original.generation {
+		t.Fatalf("Expected a new NEARLINE generation with the KMS key, got %+v", obj)
+	}
+	if string(obj.data) != "payload" || obj.metadata["owner"] != "alice" {
+		t.Fatalf("Expected the content and metadata to be kept, got %+v", obj)
+	}
+	if err := gcs.Rewrite(ctx, "missing.bin", blob.RewriteOptions{StorageClass: "COLDLINE"}); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Rewrite of a missing object should return ErrNotFound, got: %v", err)
+	}
+}
+
+func TestGcsBucket_UpdateMetadata(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGcs(t, "test-bucket")
+	fake.put("doc", []byte("data"), map[string]string{"owner": "alice", "status": "draft"})
+	gcs := fake.storage(t, "")
+
+	if err := gcs.UpdateMetadata(ctx, "doc", map[string]string{"status": "active"}, blob.MetadataMerge); err != nil {
+		t.Fatalf("UpdateMetadata in merge mode failed: %v", err)
+	}
+	if got := fake.object("doc").metadata; !reflect.DeepEqual(got, map[string]string{"owner": "alice", "status": "active"}) {
+		t.Fatalf("Merge should keep the other keys, got %v", got)
+	}
+	if err := gcs.UpdateMetadata(ctx, "doc", map[string]string{"status": "archived"}, blob.MetadataReplace); err != nil {
+		t.Fatalf("UpdateMetadata in replace mode failed: %v", err)
+	}
+	if got := fake.object("doc").metadata; !reflect.DeepEqual(got, map[string]string{"status": "archived"}) {
+		t.Fatalf("Replace should remove the keys not given, got %v", got)
+	}
+
+	// A concurrent metadata change fails the metageneration precondition
+	// instead of being lost.
+	fake.intercept = func(r *http.Request) int {
+		if r.Method == http.MethodPatch {
+			fake.mu.Lock()
+			fake.objects["doc"].metageneration++
+			fake.mu.Unlock()
+		}
+		return 0
+	}
+	err := gcs.UpdateMetadata(ctx, "doc", map[string]string{"owner": "bob"}, blob.MetadataMerge)
+	if !errors.Is(err, blob.ErrPreconditionFailed) {
+		t.Fatalf("Expected ErrPreconditionFailed for a concurrent change, got: %v", err)
+	}
+}
+
+func TestGcsBucket_ListMetadata(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGcs(t, "test-bucket")
+	fake.put("docs/a", []byte("data"), map[string]string{"owner": "alice"})
+
+	infos, err := fake.storage(t, "").ListInfo(ctx, "docs/")
+	if err != nil {
+		t.Fatalf("ListInfo failed: %v", err)
+	}
+	if len(infos) != 1 || infos[0].Size != 4 || infos[0].Metadata != nil {
+		t.Fatalf("Expected a listing without metadata by default, got %+v", infos)
+	}
+
+	infos, err = fake.storage(t, "", blob.WithListMetadata()).ListInfo(ctx, "docs/")
+	if err != nil {
+		t.Fatalf("ListInfo failed: %v", err)
+	}
+	if len(infos) != 1 || infos[0].Key != "docs/a" || infos[0].Metadata["owner"] != "alice" {
+		t.Fatalf("Expected the metadata of docs/a in the listing, got %+v", infos)
+	}
+}
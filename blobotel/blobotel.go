@@ -0,0 +1,250 @@
+// Package blobotel traces the operations of a blob.Storage with
+// OpenTelemetry. It lives in its own module, installed with
+//
+//	go get github.com/acudac-com/blob-go/blobotel
+//
+// so that importers of the blob package do not depend on OpenTelemetry.
+package blobotel
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/acudac-com/blob-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys set on every span.
+const (
+	BackendKey = attribute.Key("blob.backend") // Backend, such as "gcs".
+	KeyKey     = attribute.Key("blob.key")     // Key, folder or prefix, possibly hashed.
+	SizeKey    = attribute.Key("blob.size")    // Bytes read or written.
+)
+
+// Configures Traced.
+type Option func(*traced)
+
+// Records keys as the hex encoded SHA256 of the key instead of the key
+// itself, for keys that contain personal data. Equal keys still have equal
+// hashes, so traces of the same blob can be correlated.
+func WithHashedKeys() Option {
+	return func(t *traced) {
+		t.hashKeys = true
+	}
+}
+
+// Sets the backend attribute, which defaults to the name of the backend for
+// the storages of the blob package and to the Go type of s otherwise.
+func WithBackend(name string) Option {
+	return func(t *traced) {
+		t.backend = name
+	}
+}
+
+// Starts a span per operation.
+type traced struct {
+	blob.Storage
+	tracer   trace.Tracer
+	backend  string
+	hashKeys bool
+}
+
+// Wraps s so that every operation runs in a span named after the Storage
+// method, such as "blob.Read", started as a child of the span in the context
+// passed to the operation. Spans carry the backend, key and size attributes
+// and record the error of failed operations. Spans of readers and writers end
+// when they are closed.
+func Traced(s blob.Storage, tracer trace.Tracer, opts ...Option) blob.Storage {
+	t := &traced{Storage: s, tracer: tracer, backend: backendName(s)}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Returns the backend attribute of s.
+func backendName(s blob.Storage) string {
+	switch s.(type) {
+	case *blob.Fs:
+		return "fs"
+	case *blob.Gcs:
+		return "gcs"
+	case *blob.Redis:
+		return "redis"
+	case *blob.HTTP:
+		return "http"
+	case *blob.Mem:
+		return "mem"
+	}
+	return fmt.Sprintf("%T", s)
+}
+
+// Starts the span of an operation on key.
+func (t *traced) start(ctx context.Context, op, key string) (context.Context, trace.Span) {
+	if t.hashKeys {
+		sum := sha256.Sum256([]byte(key))
+		key = hex.EncodeToString(sum[:])
+	}
+	return t.tracer.Start(ctx, "blob."+op, trace.WithAttributes(BackendKey.String(t.backend), KeyKey.String(key)))
+}
+
+// Ends a span with the size of the operation and its error, if any.
+func end(span trace.Span, size int, err error) {
+	if size > 0 {
+		span.SetAttributes(SizeKey.Int(size))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (t *traced) Read(ctx context.Context, key string) ([]byte, error) {
+	ctx, span := t.start(ctx, "Read", key)
+	data, err := t.Storage.Read(ctx, key)
+	end(span, len(data), err)
+	return data, err
+}
+
+func (t *traced) Write(ctx context.Context, key string, data []byte) error {
+	ctx, span := t.start(ctx, "Write", key)
+	err := t.Storage.Write(ctx, key, data)
+	end(span, len(data), err)
+	return err
+}
+
+func (t *traced) WriteIfMissing(ctx context.Context, key string, data []byte) error {
+	ctx, span := t.start(ctx, "WriteIfMissing", key)
+	err := t.Storage.WriteIfMissing(ctx, key, data)
+	end(span, len(data), err)
+	return err
+}
+
+func (t *traced) Remove(ctx context.Context, key string) error {
+	ctx, span := t.start(ctx, "Remove", key)
+	err := t.Storage.Remove(ctx, key)
+	end(span, 0, err)
+	return err
+}
+
+func (t *traced) RemoveFolder(ctx context.Context, folder string) error {
+	ctx, span := t.start(ctx, "RemoveFolder", folder)
+	err := t.Storage.RemoveFolder(ctx, folder)
+	end(span, 0, err)
+	return err
+}
+
+func (t *traced) List(ctx context.Context, prefix string) ([]string, error) {
+	ctx, span := t.start(ctx, "List", prefix)
+	keys, err := t.Storage.List(ctx, prefix)
+	end(span, 0, err)
+	return keys, err
+}
+
+func (t *traced) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	ctx, span := t.start(ctx, "Reader", key)
+	rc, err := t.Storage.Reader(ctx, key)
+	if err != nil {
+		end(span, 0, err)
+		return nil, err
+	}
+	return &tracedReader{ReadCloser: rc, span: span}, nil
+}
+
+func (t *traced) ReadStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	ctx, span := t.start(ctx, "ReadStream", key)
+	rc, err := t.Storage.ReadStream(ctx, key)
+	if err != nil {
+		end(span, 0, err)
+		return nil, err
+	}
+	return &tracedReader{ReadCloser: rc, span: span}, nil
+}
+
+func (t *traced) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	ctx, span := t.start(ctx, "Writer", key)
+	wc, err := t.Storage.Writer(ctx, key)
+	if err != nil {
+		end(span, 0, err)
+		return nil, err
+	}
+	return &tracedWriter{WriteCloser: wc, span: span}, nil
+}
+
+func (t *traced) WriteStream(ctx context.Context, key string, r io.Reader) error {
+	ctx, span := t.start(ctx, "WriteStream", key)
+	cr := &countingReader{Reader: r}
+	err := t.Storage.WriteStream(ctx, key, cr)
+	end(span, cr.n, err)
+	return err
+}
+
+// Counts the bytes read through it.
+type countingReader struct {
+	io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += n
+	return n, err
+}
+
+// Ends the span of a read with the number of bytes read once closed.
+type tracedReader struct {
+	io.ReadCloser
+	span trace.Span
+	size int
+	err  error // First read error other than io.EOF.
+}
+
+func (r *tracedReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.size += n
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
+func (r *tracedReader) Close() error {
+	err := r.ReadCloser.Close()
+	if r.err != nil {
+		err = r.err
+	}
+	end(r.span, r.size, err)
+	return err
+}
+
+// Ends the span of a write with the number of bytes written once closed.
+type tracedWriter struct {
+	io.WriteCloser
+	span trace.Span
+	size int
+	err  error // First write error.
+}
+
+func (w *tracedWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	w.size += n
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
+
+func (w *tracedWriter) Close() error {
+	err := w.WriteCloser.Close()
+	if w.err != nil {
+		err = w.err
+	}
+	end(w.span, w.size, err)
+	return err
+}
@@ -0,0 +1,56 @@
+package blobotel_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+	"github.com/acudac-com/blob-go/blobotel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTraced(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	ctx, parent := tracer.Start(context.Background(), "request")
+
+	s := blobotel.Traced(blob.NewMemStorage(), tracer, blobotel.WithHashedKeys())
+	if err := s.Write(ctx, "users/1", []byte("hello")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := s.Read(ctx, "users/2"); err == nil {
+		t.Fatalf("Expected an error reading a missing blob")
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+	write, read := spans[0], spans[1]
+	if write.Name() != "blob.Write" || read.Name() != "blob.Read" {
+		t.Fatalf("Unexpected span names %s and %s", write.Name(), read.Name())
+	}
+	if write.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("Expected the span to be a child of the span in the context")
+	}
+	sum := sha256.Sum256([]byte("users/1"))
+	attrs := attribute.NewSet(write.Attributes()...)
+	if v, _ := attrs.Value(blobotel.KeyKey); v.AsString() != hex.EncodeToString(sum[:]) {
+		t.Fatalf("Expected the hashed key, got %q", v.AsString())
+	}
+	if v, _ := attrs.Value(blobotel.BackendKey); v.AsString() != "mem" {
+		t.Fatalf("Expected backend mem, got %q", v.AsString())
+	}
+	if v, _ := attrs.Value(blobotel.SizeKey); v.AsInt64() != 5 {
+		t.Fatalf("Expected size 5, got %d", v.AsInt64())
+	}
+	if read.Status().Code != codes.Error || len(read.Events()) == 0 {
+		t.Fatalf("Expected the failed read to record its error, got status %v", read.Status())
+	}
+}
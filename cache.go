@@ -0,0 +1,281 @@
+package blob
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Configures NewCached.
+type CacheOption func(*Cached)
+
+// Only caches blobs of at most n bytes, so a few large blobs cannot evict
+// many small hot ones. Defaults to the size of the whole cache.
+func WithMaxEntryBytes(n int64) CacheOption {
+	return func(c *Cached) {
+		c.maxEntryBytes = n
+	}
+}
+
+// Expires cached blobs ttl after they were read from the wrapped storage, so
+// changes made directly to it or by other processes are seen within ttl. An
+// expired blob is read again on its next read. Without a ttl, blobs stay
+// cached until they are evicted or invalidated.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *Cached) {
+		c.ttl = ttl
+	}
+}
+
+// Serves an expired blob from the cache when reading it again fails, such as
+// during a backend outage, instead of returning the error. A blob the
+// wrapped storage reports as missing is not served, and neither is a blob
+// invalidated since the failed read started, so a cold miss during an outage
+// still fails. Use ReadWithStale to tell stale data apart, and
+// CacheStats.Stale to count it. Only takes effect with WithCacheTTL.
+func WithServeStaleOnError() CacheOption {
+	return func(c *Cached) {
+		c.serveStale = true
+	}
+}
+
+// Hit and miss counts of a Cached storage.
+type CacheStats struct {
+	Hits   int64 // Reads served from the cache.
+	Misses int64 // Reads served by the wrapped storage.
+	Stale  int64 // Expired blobs served because reading them again failed.
+	Bytes  int64 // Total size of the cached blobs.
+}
+
+// A cached blob, the value of an element of the LRU list.
+type cacheEntry struct {
+	key    string
+	data   []byte
+	cached time.Time // When the blob was read from the wrapped storage.
+}
+
+// Storage that serves repeated reads of hot blobs from memory.
+type Cached struct {
+	Storage
+	maxBytes      int64
+	maxEntryBytes int64
+	ttl           time.Duration
+	serveStale    bool
+
+	mu      sync.Mutex
+	lru     *list.List               // Most recently used first.
+	entries map[string]*list.Element // Elements of lru by key.
+	bytes   int64                    // Total size of the cached blobs.
+	version uint64                   // Incremented by every invalidation.
+	stats   CacheStats
+}
+
+// Wraps s so that Read results are cached in memory, evicting the least
+// recently used blobs to keep the total size within maxBytes. Writes and
+// removals through the cache invalidate the affected keys, while changes
+// made directly to s or by other processes are only seen once a blob is
+// evicted, or once they expire with WithCacheTTL. Readers and streams are
+// served from the cache on hits but do not fill it.
+//
+// A read racing with an invalidation does not cache its result, so a read
+// never reinstates data that was overwritten meanwhile.
+func NewCached(s Storage, maxBytes int64, opts ...CacheOption) *Cached {
+	c := &Cached{Storage: s, maxBytes: maxBytes, maxEntryBytes: maxBytes, lru: list.New(), entries: map[string]*list.Element{}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Returns the hit and miss counts of reads since the cache was created.
+func (c *Cached) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Bytes = c.bytes
+	return stats
+}
+
+// Reads a blob from the cache, or from the wrapped storage on a miss.
+func (c *Cached) Read(ctx context.Context, key string) ([]byte, error) {
+	data, _, err := c.ReadWithStale(ctx, key)
+	return data, err
+}
+
+// Reads a blob like Read and reports whether the data is stale, meaning an
+// expired blob served because reading it again failed, see
+// WithServeStaleOnError.
+func (c *Cached) ReadWithStale(ctx context.Context, key string) (_ []byte, stale bool, err error) {
+	cached, expired, ok := c.get(key)
+	if ok && !expired {
+		return cached, false, nil
+	}
+	c.mu.Lock()
+	version := c.version
+	c.mu.Unlock()
+	data, err := c.Storage.Read(ctx, key)
+	if err != nil {
+		if ok && c.serveStale && !isNotFound(err) && c.countStale(version) {
+			return cached, true, nil
+		}
+		return nil, false, err
+	}
+	c.put(key, data, version)
+	return data, false, nil
+}
+
+func (c *Cached) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	if data, expired, ok := c.get(key); ok && !expired {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return c.Storage.Reader(ctx, key)
+}
+
+func (c *Cached) ReadStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	if data, expired, ok := c.get(key); ok && !expired {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return c.Storage.ReadStream(ctx, key)
+}
+
+func (c *Cached) Write(ctx context.Context, key string, data []byte) error {
+	defer c.invalidate(key)
+	return c.Storage.Write(ctx, key, data)
+}
+
+func (c *Cached) WriteIfMissing(ctx context.Context, key string, data []byte) error {
+	defer c.invalidate(key)
+	return c.Storage.WriteIfMissing(ctx, key, data)
+}
+
+// Keeps WriteNext atomic on storages that report whether they wrote.
+func (c *Cached) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	defer c.invalidate(key)
+	return writeIfMissing(ctx, c.Storage, key, data)
+}
+
+func (c *Cached) WriteStream(ctx context.Context, key string, r io.Reader) error {
+	defer c.invalidate(key)
+	return c.Storage.WriteStream(ctx, key, r)
+}
+
+// Returns a writer that invalidates the cached blob once closed.
+func (c *Cached) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	c.invalidate(key)
+	wc, err := c.Storage.Writer(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &cacheInvalidatingWriter{WriteCloser: wc, c: c, key: key}, nil
+}
+
+func (c *Cached) Remove(ctx context.Context, key string) error {
+	defer c.invalidate(key)
+	return c.Storage.Remove(ctx, key)
+}
+
+// Removes a folder and invalidates all cached blobs under it.
+func (c *Cached) RemoveFolder(ctx context.Context, folder string) error {
+	defer c.invalidateFolder(strings.Trim(folder, "/") + "/")
+	return c.Storage.RemoveFolder(ctx, folder)
+}
+
+// Returns a copy of the cached blob and whether it expired, and counts the
+// hit or miss. An expired blob counts as a miss, as it is read again.
+func (c *Cached) get(key string) (_ []byte, expired bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	expired = c.ttl > 0 && time.Since(entry.cached) > c.ttl
+	if expired {
+		c.stats.Misses++
+	} else {
+		c.stats.Hits++
+	}
+	c.lru.MoveToFront(elem)
+	return slices.Clone(entry.data), expired, true
+}
+
+// Counts a stale read unless the cache was invalidated since version, in
+// which case the stale data must not be served.
+func (c *Cached) countStale(version uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version != version {
+		return false
+	}
+	c.stats.Stale++
+	return true
+}
+
+// Caches a copy of data unless it is too large or the cache was invalidated
+// since version, then evicts blobs until the cache fits.
+func (c *Cached) put(key string, data []byte, version uint64) {
+	size := int64(len(data))
+	if size > c.maxEntryBytes || size > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version != version {
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, data: slices.Clone(data), cached: time.Now()})
+	c.bytes += size
+	for c.bytes > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Drops the cached blob of key.
+func (c *Cached) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version++
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// Drops all cached blobs whose key starts with prefix.
+func (c *Cached) invalidateFolder(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version++
+	for key, elem := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(elem)
+		}
+	}
+}
+
+// Removes an element from the cache. The caller must hold mu.
+func (c *Cached) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= int64(len(entry.data))
+}
+
+type cacheInvalidatingWriter struct {
+	io.WriteCloser
+	c   *Cached
+	key string
+}
+
+func (w *cacheInvalidatingWriter) Close() error {
+	defer w.c.invalidate(w.key)
+	return w.WriteCloser.Close()
+}
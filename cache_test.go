@@ -0,0 +1,159 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestCached(t *testing.T) {
+	ctx := context.Background()
+	mem := blob.NewMemStorage()
+	s := blob.NewCached(mem, 10, blob.WithMaxEntryBytes(4))
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := mem.Write(ctx, key, []byte(key+key+key)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	for range 2 {
+		if data, err := s.Read(ctx, "a"); err != nil || string(data) != "aaa" {
+			t.Fatalf("Read = %q, %v", data, err)
+		}
+	}
+	if stats := s.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("Expected 1 hit and 1 miss, got %+v", stats)
+	}
+
+	// Changes behind the cache are not seen, writes through it are.
+	if err := mem.Write(ctx, "a", []byte("AAA")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if data, _ := s.Read(ctx, "a"); string(data) != "aaa" {
+		t.Fatalf("Expected the cached aaa, got %q", data)
+	}
+	if err := s.Write(ctx, "a", []byte("new")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if data, _ := s.Read(ctx, "a"); string(data) != "new" {
+		t.Fatalf("Expected new after writing through the cache, got %q", data)
+	}
+
+	// Reading b and c fills the cache to 9 bytes, so a fourth blob evicts
+	// the least recently used one.
+	s.Read(ctx, "b")
+	s.Read(ctx, "c")
+	s.Read(ctx, "a")
+	if err := mem.Write(ctx, "d", []byte("ddd")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	s.Read(ctx, "d")
+	if stats := s.Stats(); stats.Bytes != 9 {
+		t.Fatalf("Expected 9 cached bytes, got %+v", stats)
+	}
+	before := s.Stats()
+	s.Read(ctx, "b")
+	if s.Stats().Misses != before.Misses+1 {
+		t.Fatalf("Expected b to be evicted")
+	}
+
+	// Blobs above the entry limit are never cached.
+	if err := mem.Write(ctx, "big", []byte("12345")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	s.Read(ctx, "big")
+	before = s.Stats()
+	s.Read(ctx, "big")
+	if s.Stats().Misses != before.Misses+1 {
+		t.Fatalf("Expected a blob above the entry limit not to be cached")
+	}
+
+	if err := mem.Write(ctx, "logs/1", []byte("1")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	s.Read(ctx, "logs/1")
+	if err := s.RemoveFolder(ctx, "logs"); err != nil {
+		t.Fatalf("RemoveFolder failed: %v", err)
+	}
+	if _, err := s.Read(ctx, "logs/1"); err == nil {
+		t.Fatalf("Expected a removed folder not to be served from the cache")
+	}
+}
+
+func TestCached_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	s := blob.NewCached(blob.NewMemStorage(), 64)
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				key := fmt.Sprintf("key/%d", j%10)
+				if i%2 == 0 {
+					s.Write(ctx, key, []byte(key))
+				} else {
+					s.Read(ctx, key)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if stats := s.Stats(); stats.Bytes > 64 {
+		t.Fatalf("Expected at most 64 cached bytes, got %+v", stats)
+	}
+}
+
+// Fails every read while down is set, like a backend outage.
+type outage struct {
+	blob.Storage
+	down atomic.Bool
+}
+
+func (o *outage) Read(ctx context.Context, key string) ([]byte, error) {
+	if o.down.Load() {
+		return nil, errors.New("backend unavailable")
+	}
+	return o.Storage.Read(ctx, key)
+}
+
+func TestCached_ServeStaleOnError(t *testing.T) {
+	ctx := context.Background()
+	backend := &outage{Storage: blob.NewMemStorage()}
+	s := blob.NewCached(backend, 1024, blob.WithCacheTTL(time.Millisecond), blob.WithServeStaleOnError())
+	if err := s.Write(ctx, "config", []byte("v1")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := s.Read(ctx, "config"); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	backend.down.Store(true)
+	data, stale, err := s.ReadWithStale(ctx, "config")
+	if err != nil || !stale || string(data) != "v1" {
+		t.Fatalf("Expected the stale blob during an outage, got %q, %v, %v", data, stale, err)
+	}
+	if stats := s.Stats(); stats.Stale != 1 {
+		t.Fatalf("Expected 1 stale read, got %+v", stats)
+	}
+	// A cold miss still fails.
+	if _, err := s.Read(ctx, "other"); err == nil {
+		t.Fatalf("Expected a cold miss to fail during an outage")
+	}
+
+	backend.down.Store(false)
+	if err := backend.Storage.Write(ctx, "config", []byte("v2")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	data, stale, err = s.ReadWithStale(ctx, "config")
+	if err != nil || stale || string(data) != "v2" {
+		t.Fatalf("Expected the expired blob to be read again, got %q, %v, %v", data, stale, err)
+	}
+}
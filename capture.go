@@ -0,0 +1,246 @@
+package blob
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Operations recorded by NewCapture.
+const (
+	opRead           = "read"
+	opWrite          = "write"
+	opWriteIfMissing = "writeIfMissing"
+	opRemove         = "remove"
+	opRemoveFolder   = "removeFolder"
+	opList           = "list"
+)
+
+// A captured operation, one JSON line of a capture.
+type captureEntry struct {
+	Op    string `json:"op"`
+	Key   string `json:"key"`             // Key, folder or prefix of the operation.
+	Size  int    `json:"size,omitempty"`  // Bytes read or written.
+	Data  []byte `json:"data,omitempty"`  // Written data, if payloads are captured.
+	Error string `json:"error,omitempty"` // Error of the operation, if it failed.
+}
+
+// Configures NewCapture.
+type CaptureOption func(*capture)
+
+// Includes the written data in the capture. Payloads may contain sensitive
+// data, so they are only captured when asked for.
+func WithPayloads() CaptureOption {
+	return func(c *capture) {
+		c.payloads = true
+	}
+}
+
+// Records every operation as a JSON line.
+type capture struct {
+	Storage
+	payloads bool
+
+	mu  sync.Mutex // Serializes writing entries.
+	enc *json.Encoder
+}
+
+// Wraps s so that every operation is appended to w as a JSON line with the
+// operation, key, size and error, for replaying it with Replay. Written data
+// is redacted unless WithPayloads is given. Failing to write to w does not
+// fail the operation. Reader and Writer are recorded as a read and a write
+// once closed.
+func NewCapture(s Storage, w io.Writer, opts ...CaptureOption) Storage {
+	c := &capture{Storage: s, enc: json.NewEncoder(w)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Appends an entry to the capture.
+func (c *capture) record(op, key string, size int, data []byte, err error) {
+	e := captureEntry{Op: op, Key: key, Size: size}
+	if c.payloads {
+		e.Data = data
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.enc.Encode(e)
+}
+
+func (c *capture) Read(ctx context.Context, key string) ([]byte, error) {
+	data, err := c.Storage.Read(ctx, key)
+	c.record(opRead, key, len(data), nil, err)
+	return data, err
+}
+
+func (c *capture) Write(ctx context.Context, key string, data []byte) error {
+	err := c.Storage.Write(ctx, key, data)
+	c.record(opWrite, key, len(data), data, err)
+	return err
+}
+
+func (c *capture) WriteIfMissing(ctx context.Context, key string, data []byte) error {
+	err := c.Storage.WriteIfMissing(ctx, key, data)
+	c.record(opWriteIfMissing, key, len(data), data, err)
+	return err
+}
+
+// Keeps WriteNext atomic on storages that report whether they wrote.
+func (c *capture) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	written, err := writeIfMissing(ctx, c.Storage, key, data)
+	c.record(opWriteIfMissing, key, len(data), data, err)
+	return written, err
+}
+
+func (c *capture) Remove(ctx context.Context, key string) error {
+	err := c.Storage.Remove(ctx, key)
+	c.record(opRemove, key, 0, nil, err)
+	return err
+}
+
+func (c *capture) RemoveFolder(ctx context.Context, folder string) error {
+	err := c.Storage.RemoveFolder(ctx, folder)
+	c.record(opRemoveFolder, folder, 0, nil, err)
+	return err
+}
+
+func (c *capture) List(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := c.Storage.List(ctx, prefix)
+	c.record(opList, prefix, 0, nil, err)
+	return keys, err
+}
+
+func (c *capture) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	rc, err := c.Storage.Reader(ctx, key)
+	if err != nil {
+		c.record(opRead, key, 0, nil, err)
+		return nil, err
+	}
+	return &captureReader{ReadCloser: rc, c: c, key: key}, nil
+}
+
+func (c *capture) ReadStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	return c.Reader(ctx, key)
+}
+
+func (c *capture) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	wc, err := c.Storage.Writer(ctx, key)
+	if err != nil {
+		c.record(opWrite, key, 0, nil, err)
+		return nil, err
+	}
+	return &captureWriter{WriteCloser: wc, c: c, key: key}, nil
+}
+
+func (c *capture) WriteStream(ctx context.Context, key string, r io.Reader) error {
+	return writeStream(ctx, c, key, r)
+}
+
+// Records a read with the number of bytes read once closed.
+type captureReader struct {
+	io.ReadCloser
+	c    *capture
+	key  string
+	size int
+	err  error // First read error other than io.EOF.
+}
+
+func (r *captureReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.size += n
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
+func (r *captureReader) Close() error {
+	err := r.ReadCloser.Close()
+	if r.err != nil {
+		err = r.err
+	}
+	r.c.record(opRead, r.key, r.size, nil, err)
+	return err
+}
+
+// Records a write with the written data once closed.
+type captureWriter struct {
+	io.WriteCloser
+	c    *capture
+	key  string
+	size int
+	data []byte // Written data, if payloads are captured.
+	err  error  // First write error.
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	w.size += n
+	if w.c.payloads {
+		w.data = append(w.data, p[:n]...)
+	}
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
+
+func (w *captureWriter) Close() error {
+	err := w.WriteCloser.Close()
+	if w.err != nil {
+		err = w.err
+	}
+	w.c.record(opWrite, w.key, w.size, w.data, err)
+	return err
+}
+
+// Re-executes the operations captured by NewCapture against target in order.
+// Writes of a capture without payloads write zero bytes of the captured size.
+// Replaying stops at the first operation that fails, unless it also failed
+// when it was captured.
+func Replay(ctx context.Context, r io.Reader, target Storage) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024) // Lines may contain large payloads
+	for line := 1; scanner.Scan(); line++ {
+		var e captureEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("decoding line %d: %w", line, err)
+		}
+		data := e.Data
+		if data == nil {
+			data = make([]byte, e.Size)
+		}
+		var err error
+		switch e.Op {
+		case opRead:
+			_, err = target.Read(ctx, e.Key)
+		case opWrite:
+			err = target.Write(ctx, e.Key, data)
+		case opWriteIfMissing:
+			err = target.WriteIfMissing(ctx, e.Key, data)
+		case opRemove:
+			err = target.Remove(ctx, e.Key)
+		case opRemoveFolder:
+			err = target.RemoveFolder(ctx, e.Key)
+		case opList:
+			_, err = target.List(ctx, e.Key)
+		default:
+			return fmt.Errorf("line %d: unknown operation %q", line, e.Op)
+		}
+		if err != nil && e.Error == "" {
+			return fmt.Errorf("replaying %s of %s on line %d: %w", e.Op, e.Key, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("scanning capture: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package blob_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestCaptureReplay(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_capture_replay"
+	defer os.RemoveAll(basePath)
+
+	var trace bytes.Buffer
+	s := blob.NewCapture(blob.NewFsStorage(basePath+"/source"), &trace, blob.WithPayloads())
+	if err := s.Write(ctx, "orders/1.json", []byte(`{"id":1}`)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := s.Write(ctx, "orders/2.json", []byte(`{"id":2}`)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := s.Remove(ctx, "orders/1.json"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := s.Read(ctx, "orders/1.json"); err == nil {
+		t.Fatalf("Read of removed blob should fail")
+	}
+	if n := strings.Count(trace.String(), "\n"); n != 4 {
+		t.Fatalf("Expected 4 captured operations, got %d: %s", n, trace.String())
+	}
+
+	target := blob.NewFsStorage(basePath + "/target")
+	if err := blob.Replay(ctx, &trace, target); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	keys, err := target.List(ctx, "")
+	if err != nil || len(keys) != 1 || keys[0] != "orders/2.json" {
+		t.Fatalf("Unexpected keys after replay: %v, %v", keys, err)
+	}
+	data, err := target.Read(ctx, "orders/2.json")
+	if err != nil || string(data) != `{"id":2}` {
+		t.Fatalf("Unexpected replayed data: %s, %v", data, err)
+	}
+}
@@ -0,0 +1,91 @@
+package blob
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Marks an upper case letter in an encoded file name.
+const caseEscape = '^'
+
+// Encodes upper case letters in file names, so keys that only differ in case
+// remain distinct on case-insensitive file systems such as the macOS and
+// Windows defaults. "User.png" is stored as "^user.png" and a literal "^" as
+// "^^". Listing decodes the names again.
+//
+// The option changes the file names on disk, so it must be used consistently
+// for a base path: blobs written without it are not found with it and vice
+// versa.
+func WithCaseEncoding() FsOption {
+	return fsOptionFunc(func(l *Fs) {
+		l.caseEncoding = true
+	})
+}
+
+// Encodes upper case letters if case encoding is enabled.
+func (l *Fs) encodeKey(key string) string {
+	if !l.caseEncoding {
+		return key
+	}
+	var b strings.Builder
+	for _, r := range key {
+		switch {
+		case r == caseEscape:
+			b.WriteRune(caseEscape)
+			b.WriteRune(caseEscape)
+		case foldsReversibly(r):
+			b.WriteRune(caseEscape)
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Decodes a key encoded with encodeKey if case encoding is enabled.
+func (l *Fs) decodeKey(key string) string {
+	if !l.caseEncoding || !strings.ContainsRune(key, caseEscape) {
+		return key
+	}
+	var b strings.Builder
+	for i := 0; i < len(key); {
+		r, size := utf8.DecodeRuneInString(key[i:])
+		i += size
+		if r == caseEscape && i < len(key) {
+			r, size = utf8.DecodeRuneInString(key[i:])
+			i += size
+			if r != caseEscape {
+				r = unicode.ToUpper(r)
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Reports whether r is upper case and survives a round trip through lower case.
+func foldsReversibly(r rune) bool {
+	lower := unicode.ToLower(r)
+	return lower != r && unicode.ToUpper(lower) == r
+}
+
+// Reports whether the directory of path contains an entry named exactly like
+// the base of path, rather than only one differing in case.
+func exactNameExists(path string) (bool, error) {
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return false, fmt.Errorf("reading directory: %w", err)
+	}
+	name := filepath.Base(path)
+	for _, entry := range entries {
+		if entry.Name() == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,62 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestLocalFiles_CaseEncoding(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_case_encoding"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath, blob.WithCaseEncoding())
+	if err := localFS.Write(ctx, "avatars/user.png", []byte("lower")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := localFS.WriteIfMissing(ctx, "avatars/User^.png", []byte("upper")); err != nil {
+		t.Fatalf("WriteIfMissing failed: %v", err)
+	}
+
+	data, err := localFS.Read(ctx, "avatars/User^.png")
+	if err != nil || string(data) != "upper" {
+		t.Fatalf("Expected upper case blob to be written separately, got %s, %v", data, err)
+	}
+	data, err = localFS.Read(ctx, "avatars/user.png")
+	if err != nil || string(data) != "lower" {
+		t.Fatalf("Expected lower case blob to be unchanged, got %s, %v", data, err)
+	}
+
+	keys, err := localFS.List(ctx, "avatars/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if !slices.Equal(keys, []string{"avatars/User^.png", "avatars/user.png"}) {
+		t.Fatalf("Unexpected keys: %v", keys)
+	}
+}
+
+func TestLocalFiles_CaseCollision(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_case_collision"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := localFS.Write(ctx, "avatars/user.png", []byte("lower")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(basePath, "avatars", "USER.PNG")); err != nil {
+		t.Skip("File system is case-sensitive")
+	}
+
+	err := localFS.WriteIfMissing(ctx, "avatars/User.png", []byte("upper"))
+	if !errors.Is(err, blob.ErrCaseCollision) {
+		t.Fatalf("WriteIfMissing should report a case collision, got: %v", err)
+	}
+}
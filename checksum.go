@@ -0,0 +1,186 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Returned when the content of a blob does not match its stored checksum.
+var ErrChecksumMismatch = errors.New("blob: checksum mismatch")
+
+// Stores the SHA256 of every blob written in its metadata sidecar, so
+// corruption can be detected with WithVerifyChecksums or Fs.VerifyAll.
+func WithChecksums() FsOption {
+	return fsOptionFunc(func(l *Fs) {
+		l.checksums = true
+	})
+}
+
+// Verifies blobs that have a stored checksum when reading them with Read,
+// Reader or ReadTo, failing with ErrChecksumMismatch if they are corrupted.
+// Ranged reads are not verified.
+func WithVerifyChecksums() FsOption {
+	return fsOptionFunc(func(l *Fs) {
+		l.verify = true
+	})
+}
+
+// Sends the CRC32C checksum of the data with every Write, so GCS rejects an
+// upload corrupted on the way, and verifies it on Read, failing with
+// ErrChecksumMismatch if the downloaded data does not match the CRC32C GCS
+// stores for the object. Verifying costs an additional metadata request per
+// read. Streaming writes and reads, and objects decompressed while
+// downloading, are covered by the checks of the GCS client only.
+func WithCRC32C() GcsOption {
+	return gcsOptionFunc(func(g *Gcs) {
+		g.crc32c = true
+	})
+}
+
+// Castagnoli polynomial table, as used by GCS for CRC32C checksums.
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Reads an object and verifies it against its stored CRC32C. The read is
+// pinned to the generation of the fetched attributes, so a concurrent
+// overwrite cannot be mistaken for corruption.
+func (g *Gcs) readVerified(ctx context.Context, name string) ([]byte, error) {
+	attrs, err := g.bucket.Object(name).Attrs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting attributes: %w", err)
+	}
+	rc, err := g.bucket.Object(name).Generation(attrs.Generation).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating reader: %w", err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("reading: %w", err)
+	}
+	if rc.Attrs.Decompressed {
+		return data, nil // The CRC32C is the one of the compressed data.
+	}
+	if got := crc32.Checksum(data, crc32cTable); got != attrs.CRC32C {
+		return nil, fmt.Errorf("%w: %s has CRC32C %08x, want %08x", ErrChecksumMismatch, name, got, attrs.CRC32C)
+	}
+	return data, nil
+}
+
+// Returns the metadata to store for newly written data, recording
+// DefaultContentType so only blobs written without a sidecar are sniffed.
+func (l *Fs) newMeta(data []byte) fsMeta {
+	m := fsMeta{ContentType: DefaultContentType}
+	if l.checksums {
+		sum := sha256.Sum256(data)
+		m.SHA256 = hex.EncodeToString(sum[:])
+	}
+	return m
+}
+
+// Checks the SHA256 computed by h against the expected hex encoded checksum.
+func checkSum(key string, h hash.Hash, want string) error {
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("%w: %s has SHA256 %s, want %s", ErrChecksumMismatch, key, got, want)
+	}
+	return nil
+}
+
+// Checks all blobs starting with the given prefix that have a stored checksum
+// and returns the keys of the corrupted ones, for periodic scrubbing.
+func (l *Fs) VerifyAll(ctx context.Context, prefix string) ([]string, error) {
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	var corrupted []string
+	err := l.walk(ctx, prefix, func(key string, info fs.FileInfo) error {
+		m, err := l.readMeta(key)
+		if err != nil {
+			return err
+		}
+		if m.SHA256 == "" {
+			return nil
+		}
+		path, err := l.filePath(key)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("opening file: %w", err)
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return fmt.Errorf("hashing %s: %w", key, err)
+		}
+		if checkSum(key, h, m.SHA256) != nil {
+			corrupted = append(corrupted, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return corrupted, nil
+}
+
+// Verifies the checksum of the read data once the end is reached.
+type verifyingReader struct {
+	io.ReadCloser
+	key  string
+	want string
+	h    hash.Hash
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.h.Write(p[:n])
+	if err == io.EOF {
+		if err := checkSum(r.key, r.h, r.want); err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
+
+// Writes a file and stores the metadata of the written data on Close.
+type fsWriter struct {
+	f   *os.File
+	l   *Fs
+	key string
+	h   hash.Hash // Hashes the written data if checksums are enabled.
+}
+
+func (w *fsWriter) Write(p []byte) (int, error) {
+	n, err := w.f.Write(p)
+	if w.h != nil {
+		w.h.Write(p[:n])
+	}
+	return n, err
+}
+
+func (w *fsWriter) Close() error {
+	if err := w.l.syncFile(w.f); err != nil {
+		w.f.Close()
+		return err
+	}
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	if err := w.l.syncDir(filepath.Dir(w.f.Name())); err != nil {
+		return err
+	}
+	m := fsMeta{ContentType: DefaultContentType}
+	if w.h != nil {
+		m.SHA256 = hex.EncodeToString(w.h.Sum(nil))
+	}
+	return w.l.writeMeta(w.key, m)
+}
@@ -0,0 +1,120 @@
+package blob_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestLocalFiles_Checksums(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_checksums"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath, blob.WithChecksums(), blob.WithVerifyChecksums())
+	if err := localFS.Write(ctx, "archive/good.txt", []byte("intact")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	w, err := localFS.Writer(ctx, "archive/rotten.txt")
+	if err != nil {
+		t.Fatalf("Writer failed: %v", err)
+	}
+	if _, err := io.WriteString(w, "original"); err != nil {
+		t.Fatalf("Writing failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Closing writer failed: %v", err)
+	}
+
+	// Sidecars are not listed as blobs
+	keys, err := localFS.List(ctx, "")
+	if err != nil || len(keys) != 2 {
+		t.Fatalf("Expected 2 blobs, got %v, %v", keys, err)
+	}
+
+	// Simulate bit rot
+	if err := os.WriteFile(filepath.Join(basePath, "archive", "rotten.txt"), []byte("origina1"), 0o644); err != nil {
+		t.Fatalf("Corrupting file failed: %v", err)
+	}
+	if _, err := localFS.Read(ctx, "archive/good.txt"); err != nil {
+		t.Fatalf("Read of intact blob failed: %v", err)
+	}
+	if _, err := localFS.Read(ctx, "archive/rotten.txt"); !errors.Is(err, blob.ErrChecksumMismatch) {
+		t.Fatalf("Read of corrupted blob should return ErrChecksumMismatch, got: %v", err)
+	}
+	rc, err := localFS.Reader(ctx, "archive/rotten.txt")
+	if err != nil {
+		t.Fatalf("Reader failed: %v", err)
+	}
+	_, err = io.ReadAll(rc)
+	rc.Close()
+	if !errors.Is(err, blob.ErrChecksumMismatch) {
+		t.Fatalf("Reader of corrupted blob should return ErrChecksumMismatch, got: %v", err)
+	}
+
+	corrupted, err := localFS.VerifyAll(ctx, "archive/")
+	if err != nil {
+		t.Fatalf("VerifyAll failed: %v", err)
+	}
+	if len(corrupted) != 1 || corrupted[0] != "archive/rotten.txt" {
+		t.Fatalf("VerifyAll = %v, want [archive/rotten.txt]", corrupted)
+	}
+}
+
+// Serves a single object whose stored CRC32C is the one of stored, while
+// downloads return body.
+type objectTransport struct {
+	stored, body []byte
+}
+
+func (t *objectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var crc [4]byte
+	binary.BigEndian.PutUint32(crc[:], crc32.Checksum(t.stored, crc32.MakeTable(crc32.Castagnoli)))
+	header := http.Header{}
+	body := t.body
+	if strings.Contains(req.URL.Path, "/storage/v1/") && req.URL.Query().Get("alt") != "media" {
+		header.Set("Content-Type", "application/json")
+		body = fmt.Appendf(nil, `{"bucket":"test-bucket","name":"data.txt","generation":"1","size":"%d","crc32c":%q}`,
+			len(t.stored), base64.StdEncoding.EncodeToString(crc[:]))
+	} else {
+		header.Set("X-Goog-Generation", "1")
+	}
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func TestGcsBucket_CRC32C(t *testing.T) {
+	ctx := context.Background()
+	stored := []byte("hello world")
+	for name, body := range map[string][]byte{"intact": stored, "corrupted": []byte("hello w0rld")} {
+		transport := &objectTransport{stored: stored, body: body}
+		gcs, err := blob.NewGcsStorage(ctx, "test-bucket", "", blob.WithCRC32C(), blob.WithHTTPClient(&http.Client{Transport: transport}))
+		if err != nil {
+			t.Fatalf("NewGcsStorage failed: %v", err)
+		}
+		data, err := gcs.Read(ctx, "data.txt")
+		if name == "intact" && (err != nil || !bytes.Equal(data, stored)) {
+			t.Fatalf("Read of an intact object = %q, %v", data, err)
+		}
+		if name == "corrupted" && !errors.Is(err, blob.ErrChecksumMismatch) {
+			t.Fatalf("Read of a corrupted object should return ErrChecksumMismatch, got: %v", err)
+		}
+	}
+}
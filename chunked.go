@@ -0,0 +1,312 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Suffix of the manifest key of a chunked blob.
+const chunkManifestName = "manifest"
+
+// Records how a chunked blob is split.
+type chunkManifest struct {
+	Chunks int   `json:"chunks"`
+	Size   int64 `json:"size"`
+}
+
+// Stores every blob as fixed-size chunks plus a manifest.
+type chunked struct {
+	Storage
+	chunkSize int64
+}
+
+// Wraps s so that every blob is split into chunks of at most chunkSize bytes,
+// stored at key/chunk-000000, key/chunk-000001, ... along with a key/manifest
+// recording the chunk count and total size. Reads concatenate the chunks, so
+// blobs can exceed the object size limit of s. Reader and Writer stream one
+// chunk at a time.
+//
+// The manifest is written last and removed first, so a new blob only becomes
+// visible once all of its chunks are written. Overwrites replace the chunks
+// in place under the old manifest, so concurrent readers may see a mix of old
+// and new content. Concurrent writes to the same key may interleave their
+// chunks, and WriteIfMissing is not atomic. Exists and Stat consult the
+// manifest.
+func NewChunked(s Storage, chunkSize int64) Storage {
+	return &chunked{Storage: s, chunkSize: chunkSize}
+}
+
+// Reads and concatenates all chunks of a blob.
+func (c *chunked) Read(ctx context.Context, key string) ([]byte, error) {
+	m, err := c.manifest(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, m.Size))
+	for i := range m.Chunks {
+		data, err := c.Storage.Read(ctx, chunkKey(key, i))
+		if err != nil {
+			return nil, fmt.Errorf("reading chunk %d: %w", i, err)
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// Splits a blob into chunks and writes them followed by the manifest.
+func (c *chunked) Write(ctx context.Context, key string, data []byte) error {
+	w, err := c.Writer(ctx, key)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// Writes a blob if no manifest exists for the key yet.
+func (c *chunked) WriteIfMissing(ctx context.Context, key string, data []byte) error {
+	_, err := c.writeIfMissing(ctx, key, data)
+	return err
+}
+
+// Writes a blob if no manifest exists for the key yet and reports whether it
+// did. Like WriteIfMissing this is not atomic, but it spares reading back the
+// whole blob.
+func (c *chunked) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	exists, err := c.Exists(ctx, key)
+	if err != nil || exists {
+		return false, err
+	}
+	return true, c.Write(ctx, key, data)
+}
+
+// Removes the manifest and all chunks of a blob.
+func (c *chunked) Remove(ctx context.Context, key string) error {
+	m, err := c.manifest(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := c.Storage.Remove(ctx, manifestKey(key)); err != nil {
+		return fmt.Errorf("removing manifest: %w", err)
+	}
+	return c.removeChunks(ctx, key, 0, m.Chunks)
+}
+
+// Lists the keys of all chunked blobs starting with the given prefix, sorted
+// by key.
+func (c *chunked) List(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := c.Storage.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var blobs []string
+	for _, k := range keys {
+		if key, ok := strings.CutSuffix(k, "/"+chunkManifestName); ok && strings.HasPrefix(key, prefix) {
+			blobs = append(blobs, key)
+		}
+	}
+	return blobs, nil
+}
+
+// Reports whether a manifest exists for the key.
+func (c *chunked) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := c.manifest(ctx, key)
+	if isNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Returns the total size recorded in the manifest along with the
+// modification time and ETag of the manifest blob, which s must be able to
+// stat.
+func (c *chunked) Stat(ctx context.Context, key string) (*BlobInfo, error) {
+	stater, ok := c.Storage.(Stater)
+	if !ok {
+		return nil, fmt.Errorf("stating %T: %w", c.Storage, errors.ErrUnsupported)
+	}
+	m, err := c.manifest(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := stater.Stat(ctx, manifestKey(key))
+	if err != nil {
+		return nil, fmt.Errorf("stating manifest: %w", err)
+	}
+	return &BlobInfo{Key: key, Size: m.Size, ModTime: info.ModTime, ETag: info.ETag}, nil
+}
+
+// Returns an io readerCloser that reads the chunks one after another.
+func (c *chunked) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	m, err := c.manifest(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &chunkReader{ctx: ctx, c: c, key: key, chunks: m.Chunks}, nil
+}
+
+// Streams the chunks one after another, like Reader.
+func (c *chunked) ReadStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	return c.Reader(ctx, key)
+}
+
+// Returns an io writerCloser that writes every full chunk right away. The
+// manifest is written on Close.
+func (c *chunked) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	if c.chunkSize <= 0 {
+		return nil, fmt.Errorf("invalid chunk size %d", c.chunkSize)
+	}
+	return &chunkWriter{ctx: ctx, c: c, key: key}, nil
+}
+
+// Writes the data read from r through Writer.
+func (c *chunked) WriteStream(ctx context.Context, key string, r io.Reader) error {
+	return writeStream(ctx, c, key, r)
+}
+
+// Reads the manifest of a blob, returning ErrNotFound if it is missing.
+func (c *chunked) manifest(ctx context.Context, key string) (chunkManifest, error) {
+	var m chunkManifest
+	data, err := c.Storage.Read(ctx, manifestKey(key))
+	if err != nil {
+		if isNotFound(err) && !errors.Is(err, ErrNotFound) {
+			err = wrapNotFound(err)
+		}
+		return m, fmt.Errorf("reading manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("decoding manifest: %w", err)
+	}
+	return m, nil
+}
+
+// Removes the chunks from index from up to, excluding to.
+func (c *chunked) removeChunks(ctx context.Context, key string, from, to int) error {
+	for i := from; i < to; i++ {
+		if err := c.Storage.Remove(ctx, chunkKey(key, i)); err != nil && !isNotFound(err) {
+			return fmt.Errorf("removing chunk %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Returns the key of the i-th chunk of a blob.
+func chunkKey(key string, i int) string {
+	return fmt.Sprintf("%s/chunk-%06d", key, i)
+}
+
+// Returns the key of the manifest of a blob.
+func manifestKey(key string) string {
+	return key + "/" + chunkManifestName
+}
+
+// Reads the chunks of a blob one after another.
+type chunkReader struct {
+	ctx    context.Context
+	c      *chunked
+	key    string
+	chunks int
+	next   int           // Index of the next chunk to open.
+	cur    io.ReadCloser // Reader of the current chunk, if any.
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur == nil {
+			if r.next >= r.chunks {
+				return 0, io.EOF
+			}
+			rc, err := r.c.Storage.Reader(r.ctx, chunkKey(r.key, r.next))
+			if err != nil {
+				return 0, fmt.Errorf("opening chunk %d: %w", r.next, err)
+			}
+			r.cur = rc
+			r.next++
+		}
+		n, err := r.cur.Read(p)
+		if err == io.EOF {
+			err = r.cur.Close()
+			r.cur = nil
+			if n > 0 || err != nil {
+				return n, err
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *chunkReader) Close() error {
+	if r.cur == nil {
+		return nil
+	}
+	err := r.cur.Close()
+	r.cur = nil
+	return err
+}
+
+// Buffers one chunk at a time and writes the manifest on Close.
+type chunkWriter struct {
+	ctx    context.Context
+	c      *chunked
+	key    string
+	buf    []byte
+	chunks int
+	size   int64
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		room := int(w.c.chunkSize) - len(w.buf)
+		take := min(room, len(p))
+		w.buf = append(w.buf, p[:take]...)
+		p = p[take:]
+		if len(w.buf) == int(w.c.chunkSize) {
+			if err := w.flush(); err != nil {
+				return n - len(p), err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Writes the buffered chunk.
+func (w *chunkWriter) flush() error {
+	if err := w.c.Storage.Write(w.ctx, chunkKey(w.key, w.chunks), w.buf); err != nil {
+		return fmt.Errorf("writing chunk %d: %w", w.chunks, err)
+	}
+	w.chunks++
+	w.size += int64(len(w.buf))
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// Writes the last chunk and the manifest, then removes chunks left over from
+// a previous, larger blob.
+func (w *chunkWriter) Close() error {
+	if len(w.buf) > 0 || w.chunks == 0 {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+	old, err := w.c.manifest(w.ctx, w.key)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	data, err := json.Marshal(chunkManifest{Chunks: w.chunks, Size: w.size})
+	if err != nil {
+		return fmt.Errorf("encoding manifest: %w", err)
+	}
+	if err := w.c.Storage.Write(w.ctx, manifestKey(w.key), data); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	return w.c.removeChunks(w.ctx, w.key, w.chunks, old.Chunks)
+}
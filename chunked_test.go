@@ -0,0 +1,66 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestChunked(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_chunked"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	s := blob.NewChunked(localFS, 4)
+	key := "big/data.bin"
+	if err := s.Write(ctx, key, []byte("0123456789")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	chunks, err := localFS.List(ctx, key+"/chunk-")
+	if err != nil || len(chunks) != 3 {
+		t.Fatalf("Expected 3 chunks, got %v, %v", chunks, err)
+	}
+
+	data, err := s.Read(ctx, key)
+	if err != nil || string(data) != "0123456789" {
+		t.Fatalf("Read = %q, %v", data, err)
+	}
+	rc, err := s.Reader(ctx, key)
+	if err != nil {
+		t.Fatalf("Reader failed: %v", err)
+	}
+	data, err = io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(data) != "0123456789" {
+		t.Fatalf("Reader read %q, %v", data, err)
+	}
+	info, err := s.(blob.Stater).Stat(ctx, key)
+	if err != nil || info.Key != key || info.Size != 10 || info.ModTime.IsZero() {
+		t.Fatalf("Stat = %+v, %v", info, err)
+	}
+	keys, err := s.List(ctx, "big/")
+	if err != nil || len(keys) != 1 || keys[0] != key {
+		t.Fatalf("List = %v, %v", keys, err)
+	}
+
+	// Overwriting with a smaller blob removes the leftover chunks
+	if err := s.Write(ctx, key, []byte("abc")); err != nil {
+		t.Fatalf("Overwrite failed: %v", err)
+	}
+	chunks, err = localFS.List(ctx, key+"/chunk-")
+	if err != nil || len(chunks) != 1 {
+		t.Fatalf("Expected 1 chunk after overwrite, got %v, %v", chunks, err)
+	}
+
+	if err := s.Remove(ctx, key); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := s.Read(ctx, key); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Read after Remove should return ErrNotFound, got: %v", err)
+	}
+}
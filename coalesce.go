@@ -0,0 +1,59 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"hash/fnv"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Number of mutexes that write coalescing spreads keys across.
+const coalesceLockStripes = 64
+
+// Collapses concurrent identical writes into a single backend write.
+type writeCoalescing struct {
+	Storage
+	group singleflight.Group
+	locks [coalesceLockStripes]sync.Mutex
+}
+
+// Wraps s so that concurrent writes of the same data to the same key share a
+// single write to s, while concurrent writes of different data to the same key
+// are serialized. This cuts redundant backend writes when bursts of requests
+// store the same result.
+//
+// Writes are only coalesced within this process and while they overlap: a
+// write started after the shared write finished writes again. The shared write
+// runs with the context of the first caller, so cancelling it fails the write
+// for all callers waiting on it. Other operations are passed through as is.
+func NewWriteCoalescing(s Storage) Storage {
+	return &writeCoalescing{Storage: s}
+}
+
+// Writes a blob, sharing the write with concurrent writes of the same data to
+// the same key.
+func (c *writeCoalescing) Write(ctx context.Context, key string, data []byte) error {
+	sum := sha256.Sum256(data)
+	_, err, _ := c.group.Do(key+"\x00"+string(sum[:]), func() (any, error) {
+		unlock := c.lock(key)
+		defer unlock()
+		return nil, c.Storage.Write(ctx, key, data)
+	})
+	return err
+}
+
+// Keeps WriteNext atomic on storages that report whether they wrote.
+func (c *writeCoalescing) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	return writeIfMissing(ctx, c.Storage, key, data)
+}
+
+// Locks the stripe of key and returns the unlock function.
+func (c *writeCoalescing) lock(key string) func() {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	mu := &c.locks[h.Sum32()%coalesceLockStripes]
+	mu.Lock()
+	return mu.Unlock
+}
@@ -0,0 +1,75 @@
+package blob_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/blob-go"
+)
+
+// Counts writes and slows them down so concurrent writes overlap.
+type slowWrites struct {
+	blob.Storage
+	writes   atomic.Int32
+	inFlight atomic.Int32
+	overlap  atomic.Bool // Set if two writes ran at the same time.
+}
+
+func (s *slowWrites) Write(ctx context.Context, key string, data []byte) error {
+	s.writes.Add(1)
+	if s.inFlight.Add(1) > 1 {
+		s.overlap.Store(true)
+	}
+	defer s.inFlight.Add(-1)
+	time.Sleep(50 * time.Millisecond)
+	return s.Storage.Write(ctx, key, data)
+}
+
+func TestWriteCoalescing(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_write_coalescing"
+	defer os.RemoveAll(basePath)
+
+	backend := &slowWrites{Storage: blob.NewFsStorage(basePath)}
+	s := blob.NewWriteCoalescing(backend)
+	key := "reports/daily.json"
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Write(ctx, key, []byte("same")); err != nil {
+				t.Errorf("Write failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := backend.writes.Load(); n != 1 {
+		t.Fatalf("Expected 1 backend write for identical writes, got %d", n)
+	}
+
+	// Different data to the same key is written once per version, one at a time.
+	backend.writes.Store(0)
+	for i := range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Write(ctx, key, fmt.Appendf(nil, "version %d", i)); err != nil {
+				t.Errorf("Write failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := backend.writes.Load(); n != 3 {
+		t.Fatalf("Expected 3 backend writes for different data, got %d", n)
+	}
+	if backend.overlap.Load() {
+		t.Fatal("Writes of different data to the same key overlapped")
+	}
+}
@@ -0,0 +1,78 @@
+package blob
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Encodes and decodes values stored by WriteObject and ReadObject.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+	// MIME type of the encoded data, set as content type on write.
+	ContentType() string
+}
+
+// Encodes values as JSON.
+var JSON Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (jsonCodec) ContentType() string                { return JSONContentType }
+
+// Implemented by storages that can write blobs with WriteOptions, such as Gcs.
+type OptionsWriter interface {
+	WriteWithOptions(ctx context.Context, key string, data []byte, opts WriteOptions) error
+}
+
+// Encodes v with codec and writes it to key. The content type of the codec is
+// set if the storage implements OptionsWriter. A nil codec uses JSON.
+func WriteObject(ctx context.Context, s Storage, key string, v any, codec Codec) error {
+	if codec == nil {
+		codec = JSON
+	}
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+	if w, ok := s.(OptionsWriter); ok {
+		return w.WriteWithOptions(ctx, key, data, WriteOptions{ContentType: codec.ContentType()})
+	}
+	return s.Write(ctx, key, data)
+}
+
+// Reads the blob at key and decodes it into v with codec. A nil codec uses
+// JSON.
+func ReadObject(ctx context.Context, s Storage, key string, v any, codec Codec) error {
+	if codec == nil {
+		codec = JSON
+	}
+	data, err := s.Read(ctx, key)
+	if err != nil {
+		return fmt.Errorf("reading: %w", err)
+	}
+	if err := codec.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decoding: %w", err)
+	}
+	return nil
+}
+
+// Encodes v as JSON and writes it to key with JSONContentType if the storage
+// implements OptionsWriter, like WriteJSON but typed for use with GetJSON.
+func PutJSON[T any](ctx context.Context, s Storage, key string, v T) error {
+	return WriteObject(ctx, s, key, v, JSON)
+}
+
+// Reads the blob at key and decodes it from JSON into a T. A missing blob
+// fails with an error matching ErrNotFound and the zero T.
+func GetJSON[T any](ctx context.Context, s Storage, key string) (T, error) {
+	var v T
+	if err := ReadObject(ctx, s, key, &v, JSON); err != nil {
+		var zero T
+		return zero, err
+	}
+	return v, nil
+}
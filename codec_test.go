@@ -0,0 +1,74 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestWriteReadObject(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_write_read_object"
+	defer os.RemoveAll(basePath)
+
+	type settings struct {
+		Theme string `json:"theme"`
+		Size  int    `json:"size"`
+	}
+	localFS := blob.NewFsStorage(basePath)
+	in := settings{Theme: "dark", Size: 12}
+	if err := blob.WriteObject(ctx, localFS, "users/1/settings.json", in, blob.JSON); err != nil {
+		t.Fatalf("WriteObject failed: %v", err)
+	}
+	var out settings
+	if err := blob.ReadObject(ctx, localFS, "users/1/settings.json", &out, nil); err != nil {
+		t.Fatalf("ReadObject failed: %v", err)
+	}
+	if out != in {
+		t.Fatalf("ReadObject = %+v, want %+v", out, in)
+	}
+}
+
+func TestPutGetJSON(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_put_get_json"
+	defer os.RemoveAll(basePath)
+
+	type event struct {
+		Name  string   `json:"name"`
+		Tags  []string `json:"tags"`
+		Count int      `json:"count"`
+	}
+	localFS := blob.NewFsStorage(basePath)
+	in := event{Name: "signup", Tags: []string{"web"}, Count: 3}
+	if err := blob.PutJSON(ctx, localFS, "events/1.json", in); err != nil {
+		t.Fatalf("PutJSON failed: %v", err)
+	}
+	info, err := localFS.Stat(ctx, "events/1.json")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.ContentType != blob.JSONContentType {
+		t.Fatalf("Expected content type %s, got %s", blob.JSONContentType, info.ContentType)
+	}
+	out, err := blob.GetJSON[event](ctx, localFS, "events/1.json")
+	if err != nil {
+		t.Fatalf("GetJSON failed: %v", err)
+	}
+	if out.Name != in.Name || out.Count != in.Count || len(out.Tags) != 1 || out.Tags[0] != "web" {
+		t.Fatalf("GetJSON = %+v, want %+v", out, in)
+	}
+
+	if _, err := blob.GetJSON[event](ctx, localFS, "events/missing.json"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("GetJSON of a missing blob should return ErrNotFound, got: %v", err)
+	}
+	if err := localFS.Write(ctx, "events/broken.json", []byte("{")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := blob.GetJSON[event](ctx, localFS, "events/broken.json"); err == nil || errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("GetJSON of invalid JSON should return a decoding error, got: %v", err)
+	}
+}
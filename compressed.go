@@ -0,0 +1,168 @@
+package blob
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// First bytes of every gzip stream, used to tell compressed from plain blobs.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Compresses blobs with gzip on write and decompresses them on read.
+type compressed struct {
+	Storage
+}
+
+// Wraps s so that data is gzip compressed on write and decompressed on read,
+// such as for JSON which compresses well. On storages implementing
+// OptionsWriter, such as Fs and Gcs, blobs are written with content encoding
+// "gzip", so other tools reading a Gcs object get it decompressed as well.
+//
+// Reads recognize compressed data by the gzip magic number, so plain blobs
+// written before the wrapper was introduced, and blobs a backend already
+// decompressed on read, are returned as stored. A plain blob that happens to
+// start with the magic number, such as a .gz file written without the
+// wrapper, is decompressed too. Streaming writes are buffered, so the
+// content encoding can be set.
+func NewCompressed(s Storage) Storage {
+	return &compressed{Storage: s}
+}
+
+// Reads a blob and decompresses it if it is gzip compressed.
+func (c *compressed) Read(ctx context.Context, key string) ([]byte, error) {
+	data, err := c.Storage.Read(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+	return decompressBytes(key, data, "gzip")
+}
+
+func (c *compressed) Write(ctx context.Context, key string, data []byte) error {
+	data, err := gzipBytes(data)
+	if err != nil {
+		return err
+	}
+	if w, ok := c.Storage.(OptionsWriter); ok {
+		return w.WriteWithOptions(ctx, key, data, WriteOptions{ContentEncoding: "gzip"})
+	}
+	return c.Storage.Write(ctx, key, data)
+}
+
+func (c *compressed) WriteIfMissing(ctx context.Context, key string, data []byte) error {
+	_, err := c.writeIfMissing(ctx, key, data)
+	return err
+}
+
+// Writes a compressed blob if the key does not contain any data yet and
+// reports whether it did. With OptionsWriter this requires generation zero,
+// which is as atomic as WriteIfMissing on Fs and Gcs.
+func (c *compressed) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	data, err := gzipBytes(data)
+	if err != nil {
+		return false, err
+	}
+	w, ok := c.Storage.(OptionsWriter)
+	if !ok {
+		return writeIfMissing(ctx, c.Storage, key, data)
+	}
+	var missing int64
+	err = w.WriteWithOptions(ctx, key, data, WriteOptions{ContentEncoding: "gzip", IfGenerationMatch: &missing})
+	if errors.Is(err, ErrPreconditionFailed) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (c *compressed) WriteStream(ctx context.Context, key string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("reading data: %w", err)
+	}
+	return c.Write(ctx, key, data)
+}
+
+// Returns a writer buffering the data and writing it compressed once closed.
+func (c *compressed) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	return &compressedWriter{ctx: ctx, c: c, key: key}, nil
+}
+
+// Returns a reader decompressing the blob if it is gzip compressed.
+func (c *compressed) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	rc, err := c.Storage.Reader(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return gunzipStream(key, rc)
+}
+
+func (c *compressed) ReadStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	rc, err := c.Storage.ReadStream(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return gunzipStream(key, rc)
+}
+
+// Compresses data with gzip.
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, fmt.Errorf("compressing: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compressing: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Returns a reader decompressing rc if it starts with the gzip magic number.
+// Closing it closes rc.
+func gunzipStream(key string, rc io.ReadCloser) (io.ReadCloser, error) {
+	br := bufio.NewReader(rc)
+	head, err := br.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		rc.Close()
+		return nil, fmt.Errorf("reading data: %w", err)
+	}
+	buffered := &bufferedReadCloser{Reader: br, Closer: rc}
+	if !bytes.Equal(head, gzipMagic) {
+		return buffered, nil
+	}
+	zrc, err := decompress(key, buffered, "gzip")
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	return zrc, nil
+}
+
+// Reads through a buffer and closes the underlying reader.
+type bufferedReadCloser struct {
+	*bufio.Reader
+	io.Closer
+}
+
+// Buffers written data until it is closed.
+type compressedWriter struct {
+	ctx context.Context
+	c   *compressed
+	key string
+	buf bytes.Buffer
+}
+
+func (w *compressedWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *compressedWriter) Close() error {
+	return w.c.Write(w.ctx, w.key, w.buf.Bytes())
+}
@@ -0,0 +1,104 @@
+package blob_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestCompressed(t *testing.T) {
+	ctx := context.Background()
+	mem := blob.NewMemStorage()
+	s := blob.NewCompressed(mem)
+
+	data := []byte(strings.Repeat(`{"name":"blob","size":42},`, 100))
+	if err := s.Write(ctx, "doc.json", data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	stored, err := mem.Read(ctx, "doc.json")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !bytes.HasPrefix(stored, []byte{0x1f, 0x8b}) || len(stored) >= len(data) {
+		t.Fatalf("Expected %d bytes to be stored gzip compressed, got %d bytes", len(data), len(stored))
+	}
+	if got, err := s.Read(ctx, "doc.json"); err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Read = %d bytes, %v, want the %d written bytes", len(got), err, len(data))
+	}
+	rc, err := s.Reader(ctx, "doc.json")
+	if err != nil {
+		t.Fatalf("Reader failed: %v", err)
+	}
+	got, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Reader = %d bytes, %v, want the %d written bytes", len(got), err, len(data))
+	}
+
+	// Blobs written before compression was enabled read as stored.
+	if err := mem.Write(ctx, "legacy", []byte("plain")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if got, err := s.Read(ctx, "legacy"); err != nil || string(got) != "plain" {
+		t.Fatalf("Read of a plain blob = %q, %v", got, err)
+	}
+	rc, err = s.ReadStream(ctx, "legacy")
+	if err != nil {
+		t.Fatalf("ReadStream failed: %v", err)
+	}
+	got, err = io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(got) != "plain" {
+		t.Fatalf("ReadStream of a plain blob = %q, %v", got, err)
+	}
+
+	if err := s.WriteIfMissing(ctx, "doc.json", []byte("other")); err != nil {
+		t.Fatalf("WriteIfMissing failed: %v", err)
+	}
+	if got, _ := s.Read(ctx, "doc.json"); !bytes.Equal(got, data) {
+		t.Fatalf("WriteIfMissing overwrote an existing blob")
+	}
+}
+
+func TestCompressed_Fs(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_compressed_fs"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	s := blob.NewCompressed(localFS)
+	if err := s.Write(ctx, "a.txt", []byte("hello")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := s.WriteIfMissing(ctx, "b.txt", []byte("world")); err != nil {
+		t.Fatalf("WriteIfMissing failed: %v", err)
+	}
+	for _, key := range []string{"a.txt", "b.txt"} {
+		info, err := localFS.Stat(ctx, key)
+		if err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		if info.ContentEncoding != "gzip" {
+			t.Fatalf("Expected content encoding gzip for %s, got %q", key, info.ContentEncoding)
+		}
+	}
+	if err := s.WriteIfMissing(ctx, "b.txt", []byte("again")); err != nil {
+		t.Fatalf("WriteIfMissing of an existing blob failed: %v", err)
+	}
+
+	// A backend decompressing on read is fine too.
+	auto := blob.NewCompressed(blob.NewFsStorage(basePath, blob.WithAutoDecompress()))
+	for key, want := range map[string]string{"a.txt": "hello", "b.txt": "world"} {
+		if got, err := s.Read(ctx, key); err != nil || string(got) != want {
+			t.Fatalf("Read(%s) = %q, %v, want %q", key, got, err, want)
+		}
+		if got, err := auto.Read(ctx, key); err != nil || string(got) != want {
+			t.Fatalf("Read(%s) with auto decompression = %q, %v, want %q", key, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+)
+
+// Preconditions for Gcs operations. Zero fields are not checked.
+type Conditions struct {
+	// Only succeed if the object's generation matches.
+	GenerationMatch int64
+	// Only succeed if the object's metageneration matches.
+	MetagenerationMatch int64
+	// Only succeed if the object does not exist yet.
+	DoesNotExist bool
+}
+
+// Gcs operations that only succeed if their preconditions hold.
+type GcsConditional struct {
+	g     *Gcs
+	conds Conditions
+}
+
+// Returns a view of the storage whose operations only succeed if conds hold,
+// failing with ErrPreconditionFailed otherwise.
+func (g *Gcs) With(conds Conditions) *GcsConditional {
+	return &GcsConditional{g: g, conds: conds}
+}
+
+// Writes a blob to Google Cloud Storage if the preconditions hold.
+func (c *GcsConditional) Write(ctx context.Context, key string, data []byte) error {
+	return c.WriteWithOptions(ctx, key, data, WriteOptions{})
+}
+
+// Writes a blob to Google Cloud Storage with the given options if the
+// preconditions hold. Without a content type the blob is stored as
+// DefaultContentType instead of letting GCS sniff one, or as detected by the
+// Sniffer of WithSniffer.
+func (c *GcsConditional) WriteWithOptions(ctx context.Context, key string, data []byte, opts WriteOptions) error {
+	key, err := c.g.objectName(key)
+	if err != nil {
+		return err
+	}
+	if !opts.RetainUntil.IsZero() {
+		if err := c.g.requireRealGcs("object retention"); err != nil {
+			return err
+		}
+	}
+	obj := c.object(key)
+	if opts.IfGenerationMatch != nil {
+		if c.conds != (Conditions{}) {
+			return fmt.Errorf("IfGenerationMatch combined with conditions %+v", c.conds)
+		}
+		gen := *opts.IfGenerationMatch
+		obj = c.g.bucket.Object(key).If(storage.Conditions{GenerationMatch: gen, DoesNotExist: gen == 0})
+	}
+	wc := obj.NewWriter(ctx)
+	wc.ContentType = opts.ContentType
+	if wc.ContentType == "" {
+		wc.ContentType = c.g.detectContentType(data)
+	}
+	wc.ContentEncoding = opts.ContentEncoding
+	wc.CacheControl = opts.CacheControl
+	wc.Metadata = opts.Metadata
+	if c.g.crc32c {
+		wc.CRC32C = crc32.Checksum(data, crc32cTable)
+		wc.SendCRC32C = true
+	}
+	if !opts.RetainUntil.IsZero() {
+		mode := "Unlocked"
+		if opts.RetentionLocked {
+			mode = "Locked"
+		}
+		wc.Retention = &storage.ObjectRetention{Mode: mode, RetainUntil: opts.RetainUntil}
+	}
+
+	if _, err := wc.Write(data); err != nil {
+		return fmt.Errorf("writing: %w", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("closing writer: %w", wrapRetained(wrapPreconditionFailed(err)))
+	}
+	return nil
+}
+
+// Removes a blob from Google Cloud Storage if the preconditions hold.
+func (c *GcsConditional) Remove(ctx context.Context, key string) error {
+	key, err := c.g.objectName(key)
+	if err != nil {
+		return err
+	}
+	if err := c.object(key).Delete(ctx); err != nil {
+		return fmt.Errorf("deleting object: %w", wrapRetained(wrapPreconditionFailed(err)))
+	}
+	return nil
+}
+
+// Returns the object handle with the preconditions applied.
+func (c *GcsConditional) object(name string) *storage.ObjectHandle {
+	obj := c.g.bucket.Object(name)
+	if c.conds == (Conditions{}) {
+		return obj // The client rejects empty conditions
+	}
+	return obj.If(storage.Conditions{
+		GenerationMatch:     c.conds.GenerationMatch,
+		MetagenerationMatch: c.conds.MetagenerationMatch,
+		DoesNotExist:        c.conds.DoesNotExist,
+	})
+}
+
+// Wraps a GCS error indicating a failed precondition so that it matches
+// ErrPreconditionFailed, other errors are returned as is.
+func wrapPreconditionFailed(err error) error {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+		return fmt.Errorf("%w: %w", ErrPreconditionFailed, err)
+	}
+	return err
+}
+
+// Wraps a GCS error indicating that an object is still retained so that it
+// matches ErrRetained, other errors are returned as is.
+func wrapRetained(err error) error {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusForbidden {
+		return err
+	}
+	if strings.Contains(strings.ToLower(apiErr.Message), "retention") {
+		return fmt.Errorf("%w: %w", ErrRetained, err)
+	}
+	for _, item := range apiErr.Errors {
+		if item.Reason == "retentionPolicyNotMet" {
+			return fmt.Errorf("%w: %w", ErrRetained, err)
+		}
+	}
+	return err
+}
+
+// Removes the blob at the given key only if it is empty, such as a folder
+// placeholder, and reports whether it was removed. The removal is conditioned
+// on the generation that was checked, so a blob that gains content in the
+// meantime is kept. Missing and non-empty blobs are not an error.
+func (g *Gcs) RemoveIfEmpty(ctx context.Context, key string) (bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	name, err := g.objectName(key)
+	if err != nil {
+		return false, err
+	}
+	attrs, err := g.bucket.Object(name).Attrs(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("getting attributes: %w", err)
+	}
+	if attrs.Size > 0 {
+		return false, nil
+	}
+	err = g.With(Conditions{GenerationMatch: attrs.Generation}).Remove(ctx, key)
+	if errors.Is(err, ErrPreconditionFailed) || errors.Is(err, storage.ErrObjectNotExist) {
+		return false, nil // Changed or removed since the check
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,79 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"cloud.google.com/go/storage"
+)
+
+// Content types set by the write helpers. Blobs written without a content type
+// are reported as DefaultContentType by every backend.
+const (
+	DefaultContentType = "application/octet-stream"
+	TextContentType    = "text/plain; charset=utf-8"
+	JSONContentType    = "application/json"
+)
+
+// Writes text to key with TextContentType if the storage implements
+// OptionsWriter.
+func WriteString(ctx context.Context, s Storage, key string, text string) error {
+	if w, ok := s.(OptionsWriter); ok {
+		return w.WriteWithOptions(ctx, key, []byte(text), WriteOptions{ContentType: TextContentType})
+	}
+	return s.Write(ctx, key, []byte(text))
+}
+
+// Encodes v as JSON and writes it to key with JSONContentType if the storage
+// implements OptionsWriter.
+func WriteJSON(ctx context.Context, s Storage, key string, v any) error {
+	return WriteObject(ctx, s, key, v, JSON)
+}
+
+// Returns the content type stored for the blob at the given key, or
+// DefaultContentType if it was written without one. Files without a recorded
+// type are detected like Stat does.
+func (l *Fs) ContentType(ctx context.Context, key string) (string, error) {
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	path, err := l.filePath(key)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("getting file info: %w", wrapNotFound(err))
+	}
+	m, err := l.readMeta(key)
+	if err != nil {
+		return "", err
+	}
+	return l.contentType(key, path, m)
+}
+
+// Returns the content type of the object at the given key, or
+// DefaultContentType if none was set.
+func (g *Gcs) ContentType(ctx context.Context, key string) (string, error) {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	name, err := g.objectName(key)
+	if err != nil {
+		return "", err
+	}
+	attrs, err := g.bucket.Object(name).Attrs(ctx)
+	if err != nil {
+		return "", fmt.Errorf("getting attributes: %w", wrapNotFound(err))
+	}
+	return gcsContentType(attrs), nil
+}
+
+// Returns the content type of attrs, or DefaultContentType if none was set.
+func gcsContentType(attrs *storage.ObjectAttrs) string {
+	if attrs.ContentType == "" {
+		return DefaultContentType
+	}
+	return attrs.ContentType
+}
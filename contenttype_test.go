@@ -0,0 +1,52 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestContentTypeDefaults(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_content_type_defaults"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := localFS.Write(ctx, "raw.bin", []byte{0x00, 0x01}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := blob.WriteString(ctx, localFS, "notes.txt", "hello"); err != nil {
+		t.Fatalf("WriteString failed: %v", err)
+	}
+	if err := blob.WriteJSON(ctx, localFS, "config.json", map[string]int{"size": 1}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+
+	for key, want := range map[string]string{
+		"raw.bin":     blob.DefaultContentType,
+		"notes.txt":   blob.TextContentType,
+		"config.json": blob.JSONContentType,
+	} {
+		got, err := localFS.ContentType(ctx, key)
+		if err != nil {
+			t.Fatalf("ContentType of %s failed: %v", key, err)
+		}
+		if got != want {
+			t.Errorf("ContentType of %s = %q, want %q", key, got, want)
+		}
+	}
+
+	// A plain write replaces the content type of a previous helper write.
+	if err := localFS.Write(ctx, "notes.txt", []byte("bye")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if got, _ := localFS.ContentType(ctx, "notes.txt"); got != blob.DefaultContentType {
+		t.Errorf("ContentType after Write = %q, want %q", got, blob.DefaultContentType)
+	}
+	if _, err := localFS.ContentType(ctx, "missing.txt"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("ContentType of missing key should return ErrNotFound, got: %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+)
+
+// Options for copying a blob within a storage. The zero value copies the
+// content type and custom metadata of the source unchanged.
+type CopyOptions struct {
+	// How Metadata is applied to the metadata of the source. The default,
+	// MetadataMerge, overrides the given keys and keeps the others.
+	MetadataMode MetadataMode
+	// Custom metadata applied to the copy according to MetadataMode.
+	Metadata map[string]string
+	// Content type of the copy. Empty keeps the content type of the source.
+	ContentType string
+}
+
+// Returns the custom metadata of a copy of a blob with the metadata src.
+func copyMetadata(src map[string]string, opts CopyOptions) (map[string]string, error) {
+	switch opts.MetadataMode {
+	case MetadataMerge:
+		if len(src) == 0 && len(opts.Metadata) == 0 {
+			return nil, nil
+		}
+		m := maps.Clone(src)
+		if m == nil {
+			m = make(map[string]string, len(opts.Metadata))
+		}
+		maps.Copy(m, opts.Metadata)
+		return m, nil
+	case MetadataReplace:
+		return maps.Clone(opts.Metadata), nil
+	case MetadataPreserve:
+		return maps.Clone(src), nil
+	default:
+		return nil, fmt.Errorf("unknown metadata mode %d", opts.MetadataMode)
+	}
+}
+
+// Copies the blob at srcKey to dstKey along with its metadata, overwriting an
+// existing blob at dstKey. Fails with ErrNotFound if the source is missing.
+func (l *Fs) Copy(ctx context.Context, srcKey, dstKey string) error {
+	return l.CopyWithOptions(ctx, srcKey, dstKey, CopyOptions{})
+}
+
+// Copies the blob at srcKey to dstKey along with its metadata sidecar, which
+// is adjusted according to opts. The copy becomes visible at dstKey only once
+// complete.
+func (l *Fs) CopyWithOptions(ctx context.Context, srcKey, dstKey string, opts CopyOptions) error {
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, err := l.filePath(srcKey)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening source: %w", wrapNotFound(err))
+	}
+	defer f.Close()
+	m, err := l.readMeta(srcKey)
+	if err != nil {
+		return err
+	}
+	if m.Metadata, err = copyMetadata(m.Metadata, opts); err != nil {
+		return err
+	}
+	if opts.ContentType != "" {
+		m.ContentType = opts.ContentType
+	}
+	if err := l.writeFrom(dstKey, f, m); err != nil {
+		return fmt.Errorf("writing copy: %w", err)
+	}
+	return nil
+}
+
+// Copies the object at srcKey to dstKey server-side, so no data passes through
+// this process, overwriting an existing object at dstKey. Fails with
+// ErrNotFound if the source is missing.
+func (g *Gcs) Copy(ctx context.Context, srcKey, dstKey string) error {
+	return g.CopyWithOptions(ctx, srcKey, dstKey, CopyOptions{})
+}
+
+// Copies the object at srcKey to dstKey within the bucket without downloading
+// it. The content type, encoding, language, disposition and cache control of
+// the source are kept, the custom metadata is adjusted according to opts.
+func (g *Gcs) CopyWithOptions(ctx context.Context, srcKey, dstKey string, opts CopyOptions) error {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	srcName, err := g.objectName(srcKey)
+	if err != nil {
+		return err
+	}
+	dstName, err := g.objectName(dstKey)
+	if err != nil {
+		return err
+	}
+	src := g.bucket.Object(srcName)
+	attrs, err := src.Attrs(ctx)
+	if err != nil {
+		return fmt.Errorf("getting attributes: %w", wrapNotFound(err))
+	}
+	metadata, err := copyMetadata(attrs.Metadata, opts)
+	if err != nil {
+		return err
+	}
+
+	// Copying the generation that was read keeps content and attributes
+	// consistent if the source changes meanwhile.
+	copier := g.bucket.Object(dstName).CopierFrom(src.Generation(attrs.Generation))
+	copier.ContentType = attrs.ContentType
+	if opts.ContentType != "" {
+		copier.ContentType = opts.ContentType
+	}
+	copier.ContentEncoding = attrs.ContentEncoding
+	copier.ContentLanguage = attrs.ContentLanguage
+	copier.ContentDisposition = attrs.ContentDisposition
+	copier.CacheControl = attrs.CacheControl
+	copier.Metadata = metadata
+	if _, err := copier.Run(ctx); err != nil {
+		return fmt.Errorf("copying object: %w", wrapNotFound(err))
+	}
+	return nil
+}
+
+// Moves the blob at srcKey to dstKey along with its metadata, overwriting an
+// existing blob at dstKey. The file is renamed, which is atomic within a file
+// system. If dstKey is on another mounted file system, the blob is copied and
+// the source removed instead. Moving a blob onto its own key does nothing.
+// Fails with ErrNotFound if the source is missing.
+func (l *Fs) Move(ctx context.Context, srcKey, dstKey string) error {
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if srcKey == dstKey {
+		return nil
+	}
+	srcPath, err := l.filePath(srcKey)
+	if err != nil {
+		return err
+	}
+	dstPath, err := l.filePath(dstKey)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(srcPath); err != nil {
+		return fmt.Errorf("stating source: %w", wrapNotFound(err))
+	}
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0o755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+	if err := os.Rename(srcPath, dstPath); err != nil {
+		if !isCrossDevice(err) {
+			return fmt.Errorf("renaming file: %w", err)
+		}
+		if err := l.Copy(ctx, srcKey, dstKey); err != nil {
+			return err
+		}
+		if err := l.Remove(ctx, srcKey); err != nil {
+			return fmt.Errorf("removing source after copying, blob exists at both keys: %w", err)
+		}
+		return nil
+	}
+	m, err := l.readMeta(srcKey)
+	if err != nil {
+		return err
+	}
+	if err := l.writeMeta(dstKey, m); err != nil {
+		return err
+	}
+	return l.removeMeta(srcKey)
+}
+
+// Moves the object at srcKey to dstKey with a server-side copy followed by a
+// delete of the source. GCS has no native move, so this is not atomic: other
+// readers may see the blob at both keys in between, and if the delete fails
+// after the copy succeeded, the blob exists at both keys and the returned
+// error says so. Moving a blob onto its own key does nothing.
+func (g *Gcs) Move(ctx context.Context, srcKey, dstKey string) error {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	if srcKey == dstKey {
+		return nil
+	}
+	if err := g.Copy(ctx, srcKey, dstKey); err != nil {
+		return err
+	}
+	if err := g.Remove(ctx, srcKey); err != nil {
+		return fmt.Errorf("removing source after copying, blob exists at both keys: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestLocalFiles_Copy(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_copy"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := blob.WriteString(ctx, localFS, "docs/readme.txt", "hello"); err != nil {
+		t.Fatalf("WriteString failed: %v", err)
+	}
+
+	if err := localFS.Copy(ctx, "docs/readme.txt", "archive/readme.txt"); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	data, err := localFS.Read(ctx, "archive/readme.txt")
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("Read of copy = %q, %v, want hello", data, err)
+	}
+	if ct, _ := localFS.ContentType(ctx, "archive/readme.txt"); ct != blob.TextContentType {
+		t.Fatalf("Copy should preserve content type %q, got %q", blob.TextContentType, ct)
+	}
+
+	// Copying onto an existing blob overwrites it.
+	if err := localFS.Write(ctx, "archive/old.txt", []byte("old")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := localFS.Copy(ctx, "docs/readme.txt", "archive/old.txt"); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if data, _ := localFS.Read(ctx, "archive/old.txt"); string(data) != "hello" {
+		t.Fatalf("Copy onto existing blob = %q, want hello", data)
+	}
+
+	opts := blob.CopyOptions{ContentType: "text/markdown", MetadataMode: blob.MetadataReplace}
+	if err := localFS.CopyWithOptions(ctx, "docs/readme.txt", "docs/readme.md", opts); err != nil {
+		t.Fatalf("CopyWithOptions failed: %v", err)
+	}
+	if ct, _ := localFS.ContentType(ctx, "docs/readme.md"); ct != "text/markdown" {
+		t.Fatalf("Copy should override content type with text/markdown, got %q", ct)
+	}
+	if ct, _ := localFS.ContentType(ctx, "docs/readme.txt"); ct != blob.TextContentType {
+		t.Fatalf("Copy changed the content type of the source to %q", ct)
+	}
+
+	if err := localFS.Copy(ctx, "docs/missing.txt", "docs/copy.txt"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Copy of missing key should return ErrNotFound, got: %v", err)
+	}
+}
+
+func TestLocalFiles_Move(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_move"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath, blob.WithChecksums(), blob.WithVerifyChecksums())
+	if err := blob.WriteJSON(ctx, localFS, "staging/42", map[string]int{"id": 42}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	if err := localFS.Write(ctx, "final/42", []byte("stale")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	if err := localFS.Move(ctx, "staging/42", "final/42"); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	data, err := localFS.Read(ctx, "final/42")
+	if err != nil || string(data) != `{"id":42}` {
+		t.Fatalf("Read after Move = %q, %v", data, err)
+	}
+	if ct, _ := localFS.ContentType(ctx, "final/42"); ct != blob.JSONContentType {
+		t.Fatalf("Move should keep the content type, got %q", ct)
+	}
+	if exists, _ := localFS.Exists(ctx, "staging/42"); exists {
+		t.Fatal("Source still exists after Move")
+	}
+	if err := localFS.Move(ctx, "staging/42", "final/43"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Move of missing key should return ErrNotFound, got: %v", err)
+	}
+
+	// Moving a blob onto itself keeps it along with its metadata.
+	if err := localFS.Move(ctx, "final/42", "final/42"); err != nil {
+		t.Fatalf("Move onto the same key failed: %v", err)
+	}
+	if data, err := localFS.Read(ctx, "final/42"); err != nil || string(data) != `{"id":42}` {
+		t.Fatalf("Read after Move onto the same key = %q, %v", data, err)
+	}
+	if ct, _ := localFS.ContentType(ctx, "final/42"); ct != blob.JSONContentType {
+		t.Fatalf("Move onto the same key should keep the content type, got %q", ct)
+	}
+}
+
+func TestGcsBucket_Move(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGcs(t, "test-bucket")
+	gcs := fake.storage(t, "")
+	fake.put("staging/42", []byte("data"), map[string]string{"owner": "alice"})
+
+	if err := gcs.Move(ctx, "staging/42", "staging/42"); err != nil {
+		t.Fatalf("Move onto the same key failed: %v", err)
+	}
+	if obj := fake.object("staging/42"); obj == nil || string(obj.data) != "data" {
+		t.Fatalf("Move onto the same key should keep the blob, got %+v", obj)
+	}
+	if err := gcs.Move(ctx, "staging/42", "final/42"); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	if obj := fake.object("final/42"); obj == nil || string(obj.data) != "data" || obj.metadata["owner"] != "alice" {
+		t.Fatalf("Expected the blob with its metadata at final/42, got %+v", obj)
+	}
+	if fake.object("staging/42") != nil {
+		t.Fatal("Source still exists after Move")
+	}
+}
@@ -0,0 +1,69 @@
+package blob
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Decompresses blobs on read according to the content encoding stored with
+// WriteWithOptions, so code reading gzip encoded blobs works the same on Fs
+// as on Gcs, whose client decompresses gzip encoded objects by default.
+// Blobs with an unknown encoding are returned as stored and a warning is
+// logged. Ranged reads return the stored data.
+func WithAutoDecompress() FsOption {
+	return fsOptionFunc(func(l *Fs) {
+		l.autoDecompress = true
+	})
+}
+
+// Returns a reader decompressing rc according to encoding. Closing it closes
+// rc.
+func decompress(key string, rc io.ReadCloser, encoding string) (io.ReadCloser, error) {
+	switch encoding {
+	case "", "identity":
+		return rc, nil
+	case "gzip":
+		zr, err := gzip.NewReader(rc)
+		if err != nil {
+			return nil, fmt.Errorf("opening gzip reader: %w", err)
+		}
+		return &gzipReadCloser{Reader: zr, rc: rc}, nil
+	default:
+		slog.Warn("blob: reading blob with unknown content encoding as stored", "key", key, "encoding", encoding)
+		return rc, nil
+	}
+}
+
+// Decompresses data according to encoding.
+func decompressBytes(key string, data []byte, encoding string) ([]byte, error) {
+	if encoding == "" {
+		return data, nil
+	}
+	rc, err := decompress(key, io.NopCloser(bytes.NewReader(data)), encoding)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err = io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing: %w", err)
+	}
+	return data, nil
+}
+
+// Closes both the gzip reader and the underlying reader.
+type gzipReadCloser struct {
+	*gzip.Reader
+	rc io.ReadCloser
+}
+
+func (r *gzipReadCloser) Close() error {
+	zerr := r.Reader.Close()
+	if err := r.rc.Close(); err != nil {
+		return err
+	}
+	return zerr
+}
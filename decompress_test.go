@@ -0,0 +1,56 @@
+package blob_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestLocalFiles_AutoDecompress(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_auto_decompress"
+	defer os.RemoveAll(basePath)
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte("hello, compressed world"))
+	zw.Close()
+
+	localFS := blob.NewFsStorage(basePath, blob.WithAutoDecompress(), blob.WithChecksums(), blob.WithVerifyChecksums())
+	opts := blob.WriteOptions{ContentType: "text/plain", ContentEncoding: "gzip"}
+	if err := localFS.WriteWithOptions(ctx, "logs/app.log", compressed.Bytes(), opts); err != nil {
+		t.Fatalf("WriteWithOptions failed: %v", err)
+	}
+	if err := localFS.Write(ctx, "logs/plain.log", []byte("plain")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	data, err := localFS.Read(ctx, "logs/app.log")
+	if err != nil || string(data) != "hello, compressed world" {
+		t.Fatalf("Read = %q, %v", data, err)
+	}
+	rc, err := localFS.Reader(ctx, "logs/app.log")
+	if err != nil {
+		t.Fatalf("Reader failed: %v", err)
+	}
+	data, err = io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(data) != "hello, compressed world" {
+		t.Fatalf("Reader read %q, %v", data, err)
+	}
+	data, err = localFS.Read(ctx, "logs/plain.log")
+	if err != nil || string(data) != "plain" {
+		t.Fatalf("Read of uncompressed blob = %q, %v", data, err)
+	}
+
+	// Without the option the stored data is returned
+	data, err = blob.NewFsStorage(basePath).Read(ctx, "logs/app.log")
+	if err != nil || !bytes.Equal(data, compressed.Bytes()) {
+		t.Fatalf("Read without AutoDecompress should return the compressed data, got %q, %v", data, err)
+	}
+}
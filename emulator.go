@@ -0,0 +1,37 @@
+package blob
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Marks the storage as talking to a GCS emulator such as fake-gcs-server.
+// NewGcsStorage also enables this when STORAGE_EMULATOR_HOST is set, which
+// the GCS client uses to connect to the emulator.
+//
+// Against the emulator, the following fail with errors.ErrUnsupported instead
+// of a cryptic emulator error: object retention, Rewrite with a KMS key,
+// Append and AppendViaCompose, which compose objects, and
+// StartResumableUpload, which ignores STORAGE_EMULATOR_HOST. All other
+// methods only use reads, writes, deletes, listings, metadata updates,
+// rewrites and bucket lookups, which the emulator supports.
+func WithEmulator() GcsOption {
+	return gcsOptionFunc(func(g *Gcs) {
+		g.emulator = true
+	})
+}
+
+// Reports whether STORAGE_EMULATOR_HOST points the GCS client at an emulator.
+func emulatorHostSet() bool {
+	return os.Getenv("STORAGE_EMULATOR_HOST") != ""
+}
+
+// Returns an error if the storage talks to an emulator, which does not
+// support the named feature.
+func (g *Gcs) requireRealGcs(feature string) error {
+	if g.emulator {
+		return fmt.Errorf("%s against the GCS emulator: %w", feature, errors.ErrUnsupported)
+	}
+	return nil
+}
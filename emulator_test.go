@@ -0,0 +1,30 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestGcsEmulator_Unsupported(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("STORAGE_EMULATOR_HOST", "localhost:4443")
+
+	gcs, err := blob.NewGcsStorage(ctx, "test-bucket", "")
+	if err != nil {
+		t.Fatalf("NewGcsStorage failed: %v", err)
+	}
+	_, err = gcs.StartResumableUpload(ctx, "upload.bin", blob.ResumableUploadOptions{})
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("StartResumableUpload against emulator should return ErrUnsupported, got: %v", err)
+	}
+	err = gcs.Rewrite(ctx, "data.bin", blob.RewriteOptions{KMSKeyName: "projects/p/locations/l/keyRings/r/cryptoKeys/k"})
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("Rewrite with KMS key against emulator should return ErrUnsupported, got: %v", err)
+	}
+	if err := gcs.Append(ctx, "log.txt", []byte("line\n")); !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("Append against emulator should return ErrUnsupported, got: %v", err)
+	}
+}
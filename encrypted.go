@@ -0,0 +1,141 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Returned when reading a blob through NewEncrypted that was not encrypted
+// with its key, or was tampered with or truncated since.
+var ErrDecryptionFailed = errors.New("blob: decryption failed")
+
+// Encrypts blobs with AES-GCM on write and decrypts them on read.
+type encrypted struct {
+	Storage
+	aead cipher.AEAD
+}
+
+// Wraps s so that data is encrypted with AES-GCM under key on write and
+// decrypted on read, independent of any encryption of the backend. The key
+// must be 16, 24 or 32 bytes long, selecting AES-128, AES-192 or AES-256.
+// Every write uses a random nonce, stored in front of the ciphertext.
+//
+// Reads fail with ErrDecryptionFailed if the data does not authenticate,
+// such as a tampered, truncated or plain blob. The ciphertext is not bound
+// to the key of the blob, so blobs can be copied and moved on s. Streaming
+// reads and writes are buffered, as the whole blob is authenticated at once.
+// To combine it with NewCompressed, compress first:
+// NewCompressed(NewEncrypted(s, key)), since ciphertext does not compress.
+func NewEncrypted(s Storage, key []byte) (Storage, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("creating GCM: %w", err)
+	}
+	return &encrypted{Storage: s, aead: aead}, nil
+}
+
+// Returns data encrypted under a random nonce, prepended to the ciphertext.
+func (e *encrypted) seal(data []byte) ([]byte, error) {
+	nonce := make([]byte, e.aead.NonceSize(), e.aead.NonceSize()+len(data)+e.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+	return e.aead.Seal(nonce, nonce, data, nil), nil
+}
+
+// Returns the decrypted data of a blob sealed by seal.
+func (e *encrypted) open(key string, data []byte) ([]byte, error) {
+	if len(data) < e.aead.NonceSize()+e.aead.Overhead() {
+		return nil, fmt.Errorf("%w: %s is too short", ErrDecryptionFailed, key)
+	}
+	nonce, ciphertext := data[:e.aead.NonceSize()], data[e.aead.NonceSize():]
+	plain, err := e.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrDecryptionFailed, key, err)
+	}
+	return plain, nil
+}
+
+func (e *encrypted) Read(ctx context.Context, key string) ([]byte, error) {
+	data, err := e.Storage.Read(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return e.open(key, data)
+}
+
+func (e *encrypted) Write(ctx context.Context, key string, data []byte) error {
+	data, err := e.seal(data)
+	if err != nil {
+		return err
+	}
+	return e.Storage.Write(ctx, key, data)
+}
+
+func (e *encrypted) WriteIfMissing(ctx context.Context, key string, data []byte) error {
+	data, err := e.seal(data)
+	if err != nil {
+		return err
+	}
+	return e.Storage.WriteIfMissing(ctx, key, data)
+}
+
+// Keeps WriteNext atomic on storages that report whether they wrote.
+func (e *encrypted) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	data, err := e.seal(data)
+	if err != nil {
+		return false, err
+	}
+	return writeIfMissing(ctx, e.Storage, key, data)
+}
+
+func (e *encrypted) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, err := e.Read(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (e *encrypted) ReadStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	return e.Reader(ctx, key)
+}
+
+func (e *encrypted) WriteStream(ctx context.Context, key string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("reading data: %w", err)
+	}
+	return e.Write(ctx, key, data)
+}
+
+// Returns a writer buffering the data and writing it encrypted once closed.
+func (e *encrypted) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	return &encryptedWriter{ctx: ctx, e: e, key: key}, nil
+}
+
+// Buffers written data until it is closed.
+type encryptedWriter struct {
+	ctx context.Context
+	e   *encrypted
+	key string
+	buf bytes.Buffer
+}
+
+func (w *encryptedWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *encryptedWriter) Close() error {
+	return w.e.Write(w.ctx, w.key, w.buf.Bytes())
+}
@@ -0,0 +1,80 @@
+package blob_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestEncrypted(t *testing.T) {
+	ctx := context.Background()
+	mem := blob.NewMemStorage()
+	key := bytes.Repeat([]byte{7}, 32)
+	s, err := blob.NewEncrypted(mem, key)
+	if err != nil {
+		t.Fatalf("NewEncrypted failed: %v", err)
+	}
+
+	data := []byte("account number 1234")
+	if err := s.Write(ctx, "secret", data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	stored, err := mem.Read(ctx, "secret")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if bytes.Contains(stored, data) {
+		t.Fatalf("Expected the stored blob to be encrypted, got %q", stored)
+	}
+	if got, err := s.Read(ctx, "secret"); err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Read = %q, %v, want %q", got, err, data)
+	}
+	rc, err := s.Reader(ctx, "secret")
+	if err != nil {
+		t.Fatalf("Reader failed: %v", err)
+	}
+	got, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Reader = %q, %v, want %q", got, err, data)
+	}
+
+	if err := s.WriteIfMissing(ctx, "secret", []byte("other")); err != nil {
+		t.Fatalf("WriteIfMissing failed: %v", err)
+	}
+	if got, _ := s.Read(ctx, "secret"); !bytes.Equal(got, data) {
+		t.Fatalf("WriteIfMissing overwrote an existing blob")
+	}
+
+	tampered := bytes.Clone(stored)
+	tampered[len(tampered)-1] ^= 1
+	for name, data := range map[string][]byte{
+		"tampered":  tampered,
+		"truncated": stored[:len(stored)-1],
+		"short":     stored[:4],
+		"plain":     []byte("not encrypted"),
+	} {
+		if err := mem.Write(ctx, name, data); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if _, err := s.Read(ctx, name); !errors.Is(err, blob.ErrDecryptionFailed) {
+			t.Fatalf("Read of a %s blob should return ErrDecryptionFailed, got: %v", name, err)
+		}
+	}
+
+	other, err := blob.NewEncrypted(mem, bytes.Repeat([]byte{8}, 32))
+	if err != nil {
+		t.Fatalf("NewEncrypted failed: %v", err)
+	}
+	if _, err := other.Read(ctx, "secret"); !errors.Is(err, blob.ErrDecryptionFailed) {
+		t.Fatalf("Read with another key should return ErrDecryptionFailed, got: %v", err)
+	}
+
+	if _, err := blob.NewEncrypted(mem, []byte("short")); err == nil {
+		t.Fatalf("Expected an error for an invalid key length")
+	}
+}
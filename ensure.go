@@ -0,0 +1,38 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+)
+
+// Reads the blob at the given key, or creates and stores it with create if it
+// is missing. When multiple callers create the same missing blob concurrently,
+// only the first write is kept and all of them return that blob.
+func GetOrCreate(ctx context.Context, s Storage, key string, create func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	data, err := s.Read(ctx, key)
+	if err == nil {
+		return data, nil
+	}
+	if !isNotFound(err) {
+		return nil, fmt.Errorf("reading: %w", err)
+	}
+
+	data, err = create(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating: %w", err)
+	}
+	written, err := WriteIfMissingOK(ctx, s, key, data)
+	if err != nil {
+		return nil, fmt.Errorf("writing if missing: %w", err)
+	}
+	if written {
+		return data, nil
+	}
+
+	// Another writer won the race, so return what it stored.
+	data, err = s.Read(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("reading back: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,36 @@
+package blob_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestGetOrCreate(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_get_or_create"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	key := "cache/report.txt"
+	calls := 0
+	create := func(ctx context.Context) ([]byte, error) {
+		calls++
+		return []byte("expensive report"), nil
+	}
+
+	for range 2 {
+		data, err := blob.GetOrCreate(ctx, localFS, key, create)
+		if err != nil {
+			t.Fatalf("GetOrCreate failed: %v", err)
+		}
+		if string(data) != "expensive report" {
+			t.Fatalf("Unexpected data: %s", data)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("Expected create to be called once, got %d", calls)
+	}
+}
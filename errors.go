@@ -0,0 +1,142 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+)
+
+// Backend independent classification of a BlobError.
+type ErrorCode int
+
+const (
+	CodeUnknown ErrorCode = iota
+	CodeNotFound
+	CodePreconditionFailed
+	CodePermissionDenied
+	CodeTooLarge
+	CodeUnavailable
+	CodeInvalidArgument
+	CodeUnsupported
+	CodeCanceled
+)
+
+var errorCodeNames = [...]string{
+	CodeUnknown:            "unknown",
+	CodeNotFound:           "not found",
+	CodePreconditionFailed: "precondition failed",
+	CodePermissionDenied:   "permission denied",
+	CodeTooLarge:           "too large",
+	CodeUnavailable:        "unavailable",
+	CodeInvalidArgument:    "invalid argument",
+	CodeUnsupported:        "unsupported",
+	CodeCanceled:           "canceled",
+}
+
+func (c ErrorCode) String() string {
+	if c < 0 || int(c) >= len(errorCodeNames) {
+		return fmt.Sprintf("ErrorCode(%d)", int(c))
+	}
+	return errorCodeNames[c]
+}
+
+// Returned by the Storage methods of the Fs, Gcs, Redis, HTTP and Mem
+// backends, so callers can use errors.As and branch on the normalized Code,
+// such as to map errors to HTTP status codes, regardless of the backend.
+type BlobError struct {
+	Backend string    // "fs", "gcs", "redis", "http" or "mem".
+	Op      string    // Name of the Storage method, such as "Read".
+	Key     string    // Key, folder or prefix of the operation.
+	Code    ErrorCode // Classification of Err.
+	Err     error     // Cause of the error.
+}
+
+func (e *BlobError) Error() string {
+	return fmt.Sprintf("%s %s %s: %v", e.Backend, e.Op, e.Key, e.Err)
+}
+
+func (e *BlobError) Unwrap() error {
+	return e.Err
+}
+
+// Matches the sentinel error of the code, so errors.Is(err, ErrNotFound)
+// holds for every error with CodeNotFound.
+func (e *BlobError) Is(target error) bool {
+	switch e.Code {
+	case CodeNotFound:
+		return target == ErrNotFound
+	case CodePreconditionFailed:
+		return target == ErrPreconditionFailed
+	}
+	return false
+}
+
+// Wraps a non-nil *err in a BlobError unless it already contains one. Backends
+// defer it in their Storage methods.
+func annotate(err *error, backend, op, key string) {
+	if *err == nil {
+		return
+	}
+	var blobErr *BlobError
+	if errors.As(*err, &blobErr) {
+		return
+	}
+	*err = &BlobError{Backend: backend, Op: op, Key: key, Code: classify(*err), Err: *err}
+}
+
+// Derives the code of a backend error.
+func classify(err error) ErrorCode {
+	switch {
+	case isNotFound(err):
+		return CodeNotFound
+	case errors.Is(err, ErrPreconditionFailed):
+		return CodePreconditionFailed
+	case errors.Is(err, fs.ErrPermission), errors.Is(err, ErrRetained):
+		return CodePermissionDenied
+	case errors.Is(err, ErrQuotaExceeded), errors.Is(err, ErrTooLarge):
+		return CodeTooLarge
+	case errors.Is(err, ErrKeyTooLong), errors.Is(err, ErrCaseCollision), errors.Is(err, ErrInvalidKey):
+		return CodeInvalidArgument
+	case errors.Is(err, errors.ErrUnsupported), errors.Is(err, ErrReadOnly):
+		return CodeUnsupported
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return CodeCanceled
+	}
+	if code := statusCode(err); code != 0 {
+		switch {
+		case code == http.StatusNotFound:
+			return CodeNotFound
+		case code == http.StatusPreconditionFailed:
+			return CodePreconditionFailed
+		case code == http.StatusUnauthorized, code == http.StatusForbidden:
+			return CodePermissionDenied
+		case code == http.StatusRequestEntityTooLarge:
+			return CodeTooLarge
+		case code == http.StatusBadRequest:
+			return CodeInvalidArgument
+		case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests, code >= 500:
+			return CodeUnavailable
+		}
+	}
+	if isNetworkError(err) {
+		return CodeUnavailable
+	}
+	return CodeUnknown
+}
+
+// Returns the HTTP status code of a GCS or HTTP backend error, or zero.
+func statusCode(err error) int {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestBlobError(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_blob_error"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	_, err := localFS.Read(ctx, "missing.txt")
+	var blobErr *blob.BlobError
+	if !errors.As(err, &blobErr) {
+		t.Fatalf("Read of missing key should return a BlobError, got: %v", err)
+	}
+	want := blob.BlobError{Backend: "fs", Op: "Read", Key: "missing.txt", Code: blob.CodeNotFound}
+	if blobErr.Backend != want.Backend || blobErr.Op != want.Op || blobErr.Key != want.Key || blobErr.Code != want.Code {
+		t.Fatalf("BlobError = %+v, want %+v", blobErr, want)
+	}
+	if !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("BlobError with CodeNotFound should match ErrNotFound, got: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/private.txt":
+			http.Error(w, "forbidden", http.StatusForbidden)
+		default:
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	remote := blob.NewHTTPStorage(server.URL, nil)
+
+	for _, tc := range []struct {
+		name string
+		err  error
+		want blob.ErrorCode
+	}{
+		{"forbidden", func() error { _, err := remote.Read(ctx, "private.txt"); return err }(), blob.CodePermissionDenied},
+		{"unavailable", func() error { _, err := remote.Read(ctx, "busy.txt"); return err }(), blob.CodeUnavailable},
+		{"read only", remote.Write(ctx, "new.txt", []byte("x")), blob.CodeUnsupported},
+	} {
+		if !errors.As(tc.err, &blobErr) || blobErr.Code != tc.want || blobErr.Backend != "http" {
+			t.Errorf("%s: expected http BlobError with code %v, got: %v", tc.name, tc.want, tc.err)
+		}
+	}
+}
+
+func TestBlobError_Mem(t *testing.T) {
+	ctx := context.Background()
+	m := blob.NewMemStorage()
+	ops := map[string]func() error{
+		"Read":       func() error { _, err := m.Read(ctx, "missing"); return err },
+		"Reader":     func() error { _, err := m.Reader(ctx, "missing"); return err },
+		"ReadStream": func() error { _, err := m.ReadStream(ctx, "missing"); return err },
+		"Remove":     func() error { return m.Remove(ctx, "missing") },
+	}
+	for op, fn := range ops {
+		var blobErr *blob.BlobError
+		if err := fn(); !errors.As(err, &blobErr) || blobErr.Backend != "mem" || blobErr.Op != op || blobErr.Code != blob.CodeNotFound {
+			t.Errorf("%s of missing key should return a mem BlobError, got: %v", op, err)
+		}
+	}
+}
@@ -0,0 +1,440 @@
+package blob_test
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/blob-go"
+	"google.golang.org/api/option"
+)
+
+// An object stored by fakeGcs.
+type fakeObject struct {
+	data           []byte
+	generation     int64
+	metageneration int64
+	contentType    string
+	metadata       map[string]string
+	storageClass   string
+	kmsKeyName     string
+	componentCount int64
+	updated        time.Time
+}
+
+// Serves the parts of the GCS JSON and XML APIs used by Gcs from memory, so
+// Gcs features can be tested without credentials or an emulator.
+type fakeGcs struct {
+	*httptest.Server
+	mu         sync.Mutex
+	bucket     string
+	objects    map[string]*fakeObject
+	generation int64
+	// Called for every request before it is served. A non-zero status is
+	// returned instead of serving the request.
+	intercept func(r *http.Request) int
+	// Counts the served requests by method and path kind, such as "GET
+	// object", "GET bucket", "GET list", "PATCH object" or "POST rewrite".
+	requests map[string]int
+}
+
+// Starts a fake GCS server for bucket, closed when the test ends.
+func newFakeGcs(t *testing.T, bucket string) *fakeGcs {
+	t.Helper()
+	f := &fakeGcs{bucket: bucket, objects: map[string]*fakeObject{}, requests: map[string]int{}}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// Returns a Gcs storage with the given prefix and options talking to f.
+func (f *fakeGcs) storage(t *testing.T, prefix string, opts ...blob.GcsOption) *blob.Gcs {
+	t.Helper()
+	opts = append([]blob.GcsOption{blob.WithClientOptions(option.WithEndpoint(f.URL+"/storage/v1/"), option.WithoutAuthentication())}, opts...)
+	gcs, err := blob.NewGcsStorage(context.Background(), f.bucket, prefix, opts...)
+	if err != nil {
+		t.Fatalf("NewGcsStorage failed: %v", err)
+	}
+	t.Cleanup(func() { gcs.Close() })
+	return gcs
+}
+
+// Stores an object directly, bypassing the API.
+func (f *fakeGcs) put(name string, data []byte, metadata map[string]string) *fakeObject {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.store(name, &fakeObject{data: data, metadata: metadata})
+}
+
+// Returns a copy of the stored object, or nil if it does not exist.
+func (f *fakeGcs) object(name string) *fakeObject {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[name]
+	if !ok {
+		return nil
+	}
+	c := *obj
+	return &c
+}
+
+// Returns the number of served requests of the given kind.
+func (f *fakeGcs) count(kind string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[kind]
+}
+
+// Stores obj under name with a new generation. f.mu must be held.
+func (f *fakeGcs) store(name string, obj *fakeObject) *fakeObject {
+	f.generation++
+	obj.generation = f.generation
+	obj.metageneration = 1
+	obj.updated = time.Now()
+	if obj.storageClass == "" {
+		obj.storageClass = "STANDARD"
+	}
+	f.objects[name] = obj
+	return obj
+}
+
+func (f *fakeGcs) serve(w http.ResponseWriter, r *http.Request) {
+	if f.intercept != nil {
+		if status := f.intercept(r); status != 0 {
+			writeFakeError(w, status)
+			return
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	parts := strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/"), "/")
+	for i, p := range parts {
+		parts[i], _ = url.PathUnescape(p)
+	}
+	switch {
+	case len(parts) >= 5 && parts[0] == "upload" && parts[4] == f.bucket:
+		f.requests["POST upload"]++
+		f.upload(w, r)
+	case len(parts) >= 4 && parts[0] == "storage" && parts[3] == f.bucket:
+		f.serveJSON(w, r, parts[4:])
+	case len(parts) >= 2 && parts[0] == f.bucket:
+		f.requests["GET media"]++
+		f.media(w, r, strings.Join(parts[1:], "/"))
+	default:
+		writeFakeError(w, http.StatusNotFound)
+	}
+}
+
+// Serves the JSON API below /storage/v1/b/{bucket}.
+func (f *fakeGcs) serveJSON(w http.ResponseWriter, r *http.Request, parts []string) {
+	q := r.URL.Query()
+	switch {
+	case len(parts) == 0:
+		f.requests["GET bucket"]++
+		writeFakeJSON(w, map[string]any{"name": f.bucket})
+	case len(parts) == 1:
+		f.requests["GET list"]++
+		f.list(w, q)
+	case len(parts) == 2 && q.Get("alt") == "media":
+		f.requests["GET media"]++
+		f.media(w, r, parts[1])
+	case len(parts) == 2:
+		f.requests[r.Method+" object"]++
+		f.serveObject(w, r, parts[1])
+	case len(parts) == 3 && parts[2] == "compose":
+		f.requests["POST compose"]++
+		f.compose(w, r, parts[1])
+	case len(parts) == 7 && parts[2] == "rewriteTo":
+		f.requests["POST rewrite"]++
+		f.rewrite(w, r, parts[1], parts[6])
+	default:
+		writeFakeError(w, http.StatusNotFound)
+	}
+}
+
+// Lists the objects matching the prefix and delimiter of q in one page.
+func (f *fakeGcs) list(w http.ResponseWriter, q url.Values) {
+	prefix, delim := q.Get("prefix"), q.Get("delimiter")
+	items := []map[string]any{}
+	var prefixes []string
+	for _, name := range slices.Sorted(maps.Keys(f.objects)) {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		if i := strings.Index(rest, delim); delim != "" && i >= 0 {
+			if p := prefix + rest[:i+len(delim)]; !slices.Contains(prefixes, p) {
+				prefixes = append(prefixes, p)
+			}
+			continue
+		}
+		items = append(items, f.resource(name, f.objects[name]))
+	}
+	// Like GCS, only return the item fields selected in a partial response.
+	if fields := q.Get("fields"); strings.Contains(fields, "items(") {
+		selected := strings.Split(strings.SplitN(strings.SplitN(fields, "items(", 2)[1], ")", 2)[0], ",")
+		for _, item := range items {
+			maps.DeleteFunc(item, func(k string, _ any) bool { return !slices.Contains(selected, k) })
+		}
+	}
+	writeFakeJSON(w, map[string]any{"kind": "storage#objects", "items": items, "prefixes": prefixes})
+}
+
+// Serves the attributes of an object, updates or deletes it.
+func (f *fakeGcs) serveObject(w http.ResponseWriter, r *http.Request, name string) {
+	obj, ok := f.objects[name]
+	if !ok {
+		writeFakeError(w, http.StatusNotFound)
+		return
+	}
+	if !fakeConditionsMatch(r.URL.Query(), obj) {
+		writeFakeError(w, http.StatusPreconditionFailed)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeFakeJSON(w, f.resource(name, obj))
+	case http.MethodPatch:
+		// Like GCS, a patch merges metadata keys, deleting those set to
+		// null, and clears all metadata when it is null itself.
+		var patch struct {
+			ContentType *string         `json:"contentType"`
+			Metadata    json.RawMessage `json:"metadata"`
+		}
+		var metadata map[string]*string
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil || (patch.Metadata != nil && json.Unmarshal(patch.Metadata, &metadata) != nil) {
+			writeFakeError(w, http.StatusBadRequest)
+			return
+		}
+		if patch.ContentType != nil {
+			obj.contentType = *patch.ContentType
+		}
+		if string(patch.Metadata) == "null" {
+			obj.metadata = nil
+		}
+		for k, v := range metadata {
+			if obj.metadata == nil {
+				obj.metadata = map[string]string{}
+			}
+			if v == nil {
+				delete(obj.metadata, k)
+			} else {
+				obj.metadata[k] = *v
+			}
+		}
+		obj.metageneration++
+		writeFakeJSON(w, f.resource(name, obj))
+	case http.MethodDelete:
+		delete(f.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeFakeError(w, http.StatusMethodNotAllowed)
+	}
+}
+
+// Serves the content of an object, honouring a Range header.
+func (f *fakeGcs) media(w http.ResponseWriter, r *http.Request, name string) {
+	obj, ok := f.objects[name]
+	if !ok {
+		writeFakeError(w, http.StatusNotFound)
+		return
+	}
+	data := obj.data
+	status := http.StatusOK
+	if rng := r.Header.Get("Range"); rng != "" {
+		var start, end int64
+		end = int64(len(data)) - 1
+		spec := strings.TrimPrefix(rng, "bytes=")
+		from, to, _ := strings.Cut(spec, "-")
+		start, _ = strconv.ParseInt(from, 10, 64)
+		if to != "" {
+			end, _ = strconv.ParseInt(to, 10, 64)
+		}
+		end = min(end, int64(len(data))-1)
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+		data = data[start : end+1]
+		status = http.StatusPartialContent
+	}
+	w.Header().Set("Content-Type", obj.contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Last-Modified", obj.updated.UTC().Format(http.TimeFormat))
+	w.Header().Set("X-Goog-Generation", strconv.FormatInt(obj.generation, 10))
+	w.Header().Set("X-Goog-Metageneration", strconv.FormatInt(obj.metageneration, 10))
+	w.Header().Set("X-Goog-Stored-Content-Length", strconv.Itoa(len(obj.data)))
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// Stores the object of a multipart upload.
+func (f *fakeGcs) upload(w http.ResponseWriter, r *http.Request) {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || r.URL.Query().Get("uploadType") != "multipart" {
+		writeFakeError(w, http.StatusBadRequest)
+		return
+	}
+	mr := multipart.NewReader(r.Body, params["boundary"])
+	var attrs struct {
+		Name        string            `json:"name"`
+		ContentType string            `json:"contentType"`
+		Metadata    map[string]string `json:"metadata"`
+	}
+	part, err := mr.NextPart()
+	if err != nil || json.NewDecoder(part).Decode(&attrs) != nil {
+		writeFakeError(w, http.StatusBadRequest)
+		return
+	}
+	part, err = mr.NextPart()
+	if err != nil {
+		writeFakeError(w, http.StatusBadRequest)
+		return
+	}
+	data, err := io.ReadAll(part)
+	if err != nil {
+		writeFakeError(w, http.StatusBadRequest)
+		return
+	}
+	if !fakeConditionsMatch(r.URL.Query(), f.objects[attrs.Name]) {
+		writeFakeError(w, http.StatusPreconditionFailed)
+		return
+	}
+	obj := f.store(attrs.Name, &fakeObject{data: data, contentType: attrs.ContentType, metadata: attrs.Metadata})
+	writeFakeJSON(w, f.resource(attrs.Name, obj))
+}
+
+// Concatenates the source objects into the destination object.
+func (f *fakeGcs) compose(w http.ResponseWriter, r *http.Request, name string) {
+	var req struct {
+		SourceObjects []struct {
+			Name       string `json:"name"`
+			Generation int64  `json:"generation,string"`
+		} `json:"sourceObjects"`
+		Destination struct {
+			ContentType string            `json:"contentType"`
+			Metadata    map[string]string `json:"metadata"`
+		} `json:"destination"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeFakeError(w, http.StatusBadRequest)
+		return
+	}
+	if !fakeConditionsMatch(r.URL.Query(), f.objects[name]) {
+		writeFakeError(w, http.StatusPreconditionFailed)
+		return
+	}
+	composed := &fakeObject{contentType: req.Destination.ContentType, metadata: req.Destination.Metadata}
+	for _, src := range req.SourceObjects {
+		obj, ok := f.objects[src.Name]
+		if !ok || (src.Generation != 0 && src.Generation != obj.generation) {
+			writeFakeError(w, http.StatusNotFound)
+			return
+		}
+		composed.data = append(composed.data, obj.data...)
+		composed.componentCount += max(obj.componentCount, 1)
+	}
+	writeFakeJSON(w, f.resource(name, f.store(name, composed)))
+}
+
+// Rewrites the source object to the destination in two calls, to exercise
+// the rewrite token handling of the client.
+func (f *fakeGcs) rewrite(w http.ResponseWriter, r *http.Request, src, dst string) {
+	obj, ok := f.objects[src]
+	if !ok {
+		writeFakeError(w, http.StatusNotFound)
+		return
+	}
+	size := strconv.Itoa(len(obj.data))
+	if r.URL.Query().Get("rewriteToken") == "" {
+		writeFakeJSON(w, map[string]any{"kind": "storage#rewriteResponse", "done": false,
+			"rewriteToken": "continue", "totalBytesRewritten": "0", "objectSize": size})
+		return
+	}
+	var attrs struct {
+		ContentType  string            `json:"contentType"`
+		Metadata     map[string]string `json:"metadata"`
+		StorageClass string            `json:"storageClass"`
+	}
+	json.NewDecoder(r.Body).Decode(&attrs)
+	if !fakeConditionsMatch(r.URL.Query(), f.objects[dst]) {
+		writeFakeError(w, http.StatusPreconditionFailed)
+		return
+	}
+	rewritten := &fakeObject{
+		data:         obj.data,
+		contentType:  cmp.Or(attrs.ContentType, obj.contentType),
+		metadata:     obj.metadata,
+		storageClass: cmp.Or(attrs.StorageClass, obj.storageClass),
+		kmsKeyName:   cmp.Or(r.URL.Query().Get("destinationKmsKeyName"), obj.kmsKeyName),
+	}
+	if attrs.Metadata != nil {
+		rewritten.metadata = attrs.Metadata
+	}
+	rewritten = f.store(dst, rewritten)
+	writeFakeJSON(w, map[string]any{"kind": "storage#rewriteResponse", "done": true,
+		"totalBytesRewritten": size, "objectSize": size, "resource": f.resource(dst, rewritten)})
+}
+
+// Returns the JSON resource of an object.
+func (f *fakeGcs) resource(name string, obj *fakeObject) map[string]any {
+	res := map[string]any{
+		"kind":           "storage#object",
+		"bucket":         f.bucket,
+		"name":           name,
+		"size":           strconv.Itoa(len(obj.data)),
+		"generation":     strconv.FormatInt(obj.generation, 10),
+		"metageneration": strconv.FormatInt(obj.metageneration, 10),
+		"contentType":    obj.contentType,
+		"metadata":       obj.metadata,
+		"storageClass":   obj.storageClass,
+		"updated":        obj.updated.UTC().Format(time.RFC3339Nano),
+		"etag":           fmt.Sprintf("etag-%d-%d", obj.generation, obj.metageneration),
+	}
+	if obj.kmsKeyName != "" {
+		res["kmsKeyName"] = obj.kmsKeyName
+	}
+	if obj.componentCount > 0 {
+		res["componentCount"] = obj.componentCount
+	}
+	return res
+}
+
+// Reports whether obj, nil if missing, satisfies the preconditions of q.
+func fakeConditionsMatch(q url.Values, obj *fakeObject) bool {
+	if v := q.Get("ifGenerationMatch"); v != "" {
+		gen, _ := strconv.ParseInt(v, 10, 64)
+		if (gen == 0) != (obj == nil) || (obj != nil && obj.generation != gen) {
+			return false
+		}
+	}
+	if v := q.Get("ifMetagenerationMatch"); v != "" {
+		metagen, _ := strconv.ParseInt(v, 10, 64)
+		if obj == nil || obj.metageneration != metagen {
+			return false
+		}
+	}
+	return true
+}
+
+func writeFakeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeFakeError(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"error":{"code":%d,"message":%q}}`, status, http.StatusText(status))
+}
@@ -0,0 +1,50 @@
+package blob
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// Adapts a blob or folder to fs.FileInfo.
+type blobFileInfo struct {
+	info BlobInfo
+	dir  bool
+}
+
+// Returns the blob info as fs.FileInfo for use with io/fs based tooling. Its
+// Sys method returns the BlobInfo.
+func (b BlobInfo) FileInfo() fs.FileInfo {
+	return blobFileInfo{info: b}
+}
+
+// Returns an fs.FileInfo describing the folder at the given path, a prefix
+// with children. Blob storages do not track folders, so its size is zero and
+// its modification time unknown.
+func FolderFileInfo(folder string) fs.FileInfo {
+	return blobFileInfo{info: BlobInfo{Key: strings.TrimSuffix(folder, "/")}, dir: true}
+}
+
+// Returns the base name of the key.
+func (f blobFileInfo) Name() string {
+	return path.Base(f.info.Key)
+}
+
+func (f blobFileInfo) Size() int64 {
+	if f.dir {
+		return 0
+	}
+	return f.info.Size
+}
+
+func (f blobFileInfo) Mode() fs.FileMode {
+	if f.dir {
+		return fs.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+func (f blobFileInfo) ModTime() time.Time { return f.info.ModTime }
+func (f blobFileInfo) IsDir() bool        { return f.dir }
+func (f blobFileInfo) Sys() any           { return f.info }
@@ -0,0 +1,21 @@
+package blob_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestBlobInfo_FileInfo(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	fi := blob.BlobInfo{Key: "docs/2024/report.pdf", Size: 42, ModTime: modTime}.FileInfo()
+	if fi.Name() != "report.pdf" || fi.Size() != 42 || fi.IsDir() || !fi.ModTime().Equal(modTime) {
+		t.Fatalf("Unexpected file info: %s %d %v %v", fi.Name(), fi.Size(), fi.IsDir(), fi.ModTime())
+	}
+
+	dir := blob.FolderFileInfo("docs/2024/")
+	if dir.Name() != "2024" || dir.Size() != 0 || !dir.IsDir() || !dir.Mode().IsDir() {
+		t.Fatalf("Unexpected folder info: %s %d %v %v", dir.Name(), dir.Size(), dir.IsDir(), dir.Mode())
+	}
+}
@@ -0,0 +1,110 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// Stops a walk once the first blob is found.
+var errFound = errors.New("found")
+
+// Returns the key prefix of the blobs strictly under folder, as removed by
+// RemoveFolder.
+func folderPrefix(folder string) string {
+	if folder = strings.Trim(folder, "/"); folder == "" {
+		return ""
+	}
+	return folder + "/"
+}
+
+// Reports whether at least one blob exists under folder+"/", stopping at the
+// first one found. Unlike IsFolder, a directory without blobs, such as one
+// left empty by removes, does not count. Returns false, nil if nothing
+// matches.
+func (l *Fs) FolderExists(ctx context.Context, folder string) (_ bool, err error) {
+	defer annotate(&err, "fs", "FolderExists", folder)
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	err = l.walk(ctx, folderPrefix(folder), func(string, fs.FileInfo) error {
+		return errFound
+	})
+	if errors.Is(err, errFound) {
+		return true, nil
+	}
+	return false, err
+}
+
+// Reports whether at least one object exists under folder+"/", listing a
+// single object at most. Returns false, nil if nothing matches. IsFolder is
+// the same on GCS, which has no empty folders.
+func (g *Gcs) FolderExists(ctx context.Context, folder string) (_ bool, err error) {
+	defer annotate(&err, "gcs", "FolderExists", folder)
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	query := &storage.Query{Prefix: g.fullPrefix(folderPrefix(folder))}
+	if err := query.SetAttrSelection([]string{"Name"}); err != nil {
+		return false, fmt.Errorf("selecting attributes: %w", err)
+	}
+	it := g.bucket.Objects(ctx, query)
+	it.PageInfo().MaxSize = 1
+	_, err = it.Next()
+	if err == iterator.Done {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("iterating objects: %w", err)
+	}
+	return true, nil
+}
+
+// Returns the total size in bytes of the blobs under folder+"/", the blobs
+// RemoveFolder removes, so a blob named like the folder is not counted.
+func (l *Fs) FolderSize(ctx context.Context, folder string) (_ int64, err error) {
+	defer annotate(&err, "fs", "FolderSize", folder)
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	var total int64
+	err = l.walk(ctx, folderPrefix(folder), func(_ string, info fs.FileInfo) error {
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// Returns the total size in bytes of the objects under folder+"/", the
+// objects RemoveFolder removes, so an object named like the folder is not
+// counted. Only the sizes are listed, page by page, and cancelling ctx stops
+// the listing.
+func (g *Gcs) FolderSize(ctx context.Context, folder string) (_ int64, err error) {
+	defer annotate(&err, "gcs", "FolderSize", folder)
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	query := &storage.Query{Prefix: g.fullPrefix(folderPrefix(folder))}
+	if err := query.SetAttrSelection([]string{"Size"}); err != nil {
+		return 0, fmt.Errorf("selecting attributes: %w", err)
+	}
+	var total int64
+	it := g.bucket.Objects(ctx, query)
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		objAttrs, err := it.Next()
+		if err == iterator.Done {
+			return total, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("iterating objects: %w", err)
+		}
+		total += objAttrs.Size
+	}
+}
@@ -0,0 +1,104 @@
+package blob_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestLocalFiles_FolderExists(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_folder_exists"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := localFS.Write(ctx, "reports/2024/jan.csv", []byte("data")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := localFS.Write(ctx, "report", []byte("data")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(basePath, "empty", "nested"), 0o755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	tests := []struct {
+		folder string
+		want   bool
+	}{
+		{"reports", true},
+		{"reports/", true},
+		{"reports/2024", true},
+		{"reports/2025", false},
+		{"report", false}, // A blob, not a folder
+		{"empty", false},  // Directories without blobs
+		{"missing", false},
+	}
+	for _, tt := range tests {
+		exists, err := localFS.FolderExists(ctx, tt.folder)
+		if err != nil {
+			t.Fatalf("FolderExists(%q) failed: %v", tt.folder, err)
+		}
+		if exists != tt.want {
+			t.Fatalf("FolderExists(%q) = %v, want %v", tt.folder, exists, tt.want)
+		}
+	}
+	// Unlike FolderExists, IsFolder counts an empty directory.
+	if folder, err := localFS.IsFolder(ctx, "empty"); err != nil || !folder {
+		t.Fatalf("IsFolder of an empty directory = %v, %v, want true", folder, err)
+	}
+}
+
+func TestGcsBucket_FolderExists(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGcs(t, "test-bucket")
+	fake.put("app/reports/2024/jan.csv", []byte("data"), nil)
+	fake.put("app/report", []byte("data"), nil)
+	gcs := fake.storage(t, "app")
+
+	for folder, want := range map[string]bool{"reports": true, "reports/2024": true, "reports/2025": false, "report": false} {
+		exists, err := gcs.FolderExists(ctx, folder)
+		if err != nil || exists != want {
+			t.Fatalf("FolderExists(%q) = %v, %v, want %v", folder, exists, err, want)
+		}
+		if isFolder, err := gcs.IsFolder(ctx, folder); err != nil || isFolder != exists {
+			t.Fatalf("IsFolder(%q) = %v, %v, want the same as FolderExists", folder, isFolder, err)
+		}
+	}
+}
+
+func TestLocalFiles_FolderSize(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_folder_size"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath, blob.WithChecksums())
+	for key, data := range map[string]string{
+		"tenants/1/a.txt":     "12345",
+		"tenants/1/sub/b.txt": "123",
+		"tenants/10/c.txt":    "not counted",
+	} {
+		if err := localFS.Write(ctx, key, []byte(data)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	size, err := localFS.FolderSize(ctx, "tenants/1")
+	if err != nil {
+		t.Fatalf("FolderSize failed: %v", err)
+	}
+	if size != 8 {
+		t.Fatalf("Expected 8 bytes under tenants/1/, got %d", size)
+	}
+	if size, err := localFS.FolderSize(ctx, "missing"); err != nil || size != 0 {
+		t.Fatalf("FolderSize of a missing folder = %d, %v, want 0", size, err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := localFS.FolderSize(canceled, "tenants"); err == nil {
+		t.Fatalf("Expected an error for a canceled context")
+	}
+}
@@ -0,0 +1,92 @@
+package blob
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Folder under the Fs base path that mirrors the blob tree with a metadata
+// sidecar file per blob. Keys must not start with it.
+const fsMetaDir = ".blob-meta"
+
+// Metadata of an Fs blob, stored in its sidecar file.
+type fsMeta struct {
+	SHA256          string            `json:"sha256,omitempty"`          // Hex encoded SHA256 of the stored content.
+	ContentType     string            `json:"contentType,omitempty"`     // MIME type of the content.
+	ContentEncoding string            `json:"contentEncoding,omitempty"` // Compression of the stored content.
+	CacheControl    string            `json:"cacheControl,omitempty"`    // Caching directive for serving the content.
+	Metadata        map[string]string `json:"metadata,omitempty"`        // Custom metadata.
+}
+
+// Reports whether m holds no metadata.
+func (m fsMeta) empty() bool {
+	return m.SHA256 == "" && m.ContentType == "" && m.ContentEncoding == "" && m.CacheControl == "" &&
+		len(m.Metadata) == 0
+}
+
+// Returns the sidecar path of a key.
+func (l *Fs) metaPath(key string) (string, error) {
+	if _, err := l.filePath(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(l.basePath, fsMetaDir, filepath.FromSlash(l.encodeKey(key))), nil
+}
+
+// Reads the metadata of a blob, which is empty if it has no sidecar.
+func (l *Fs) readMeta(key string) (fsMeta, error) {
+	var m fsMeta
+	path, err := l.metaPath(key)
+	if err != nil {
+		return m, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return m, fmt.Errorf("reading metadata: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("decoding metadata: %w", err)
+	}
+	return m, nil
+}
+
+// Writes the metadata of a blob. Empty metadata removes the sidecar, so no
+// stale metadata of a previous write remains.
+func (l *Fs) writeMeta(key string, m fsMeta) error {
+	if m.empty() {
+		return l.removeMeta(key)
+	}
+	path, err := l.metaPath(key)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("encoding metadata: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating metadata directory: %w", err)
+	}
+	if err := l.writeFile(path, data); err != nil {
+		return fmt.Errorf("writing metadata: %w", err)
+	}
+	return nil
+}
+
+// Removes the sidecar of a blob if it exists.
+func (l *Fs) removeMeta(key string) error {
+	path, err := l.metaPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing metadata: %w", err)
+	}
+	return nil
+}
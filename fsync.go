@@ -0,0 +1,61 @@
+package blob
+
+import (
+	"fmt"
+	"os"
+)
+
+// Makes writes durable before they return: the data of every written file is
+// flushed to disk with fsync and, on systems that support it, so is the
+// directory the blob's file was renamed into. Without it, writes only reach
+// the operating system's page cache and can be lost on a power failure.
+//
+// Syncing waits for the disk, which typically makes every write take
+// milliseconds instead of microseconds, so it is off by default. Directories
+// that a write creates are not synced themselves, so the first blob in a new
+// folder is only durable once its parent directories are.
+func WithFsync() FsOption {
+	return fsOptionFunc(func(l *Fs) {
+		l.fsync = true
+	})
+}
+
+// Flushes the data of f to disk if fsync is enabled.
+func (l *Fs) syncFile(f *os.File) error {
+	if !l.fsync {
+		return nil
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("syncing file: %w", err)
+	}
+	return nil
+}
+
+// Flushes the entries of the directory at path to disk if fsync is enabled,
+// so files renamed into it survive a power failure.
+func (l *Fs) syncDir(path string) error {
+	if !l.fsync {
+		return nil
+	}
+	if err := syncDir(path); err != nil {
+		return fmt.Errorf("syncing directory: %w", err)
+	}
+	return nil
+}
+
+// Writes data to the file at path, syncing it if fsync is enabled.
+func (l *Fs) writeFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := l.syncFile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
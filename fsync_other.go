@@ -0,0 +1,15 @@
+//go:build !windows
+
+package blob
+
+import "os"
+
+// Flushes the entries of the directory at path to disk.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
@@ -0,0 +1,52 @@
+package blob_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestLocalFiles_Fsync(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_fsync"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath, blob.WithFsync(), blob.WithChecksums())
+	if err := localFS.Write(ctx, "a/write.txt", []byte("write")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := localFS.WriteIfMissing(ctx, "a/missing.txt", []byte("missing")); err != nil {
+		t.Fatalf("WriteIfMissing failed: %v", err)
+	}
+	if err := localFS.WriteStream(ctx, "b/stream.txt", strings.NewReader("stream")); err != nil {
+		t.Fatalf("WriteStream failed: %v", err)
+	}
+	w, err := localFS.Writer(ctx, "b/writer.txt")
+	if err != nil {
+		t.Fatalf("Writer failed: %v", err)
+	}
+	if _, err := w.Write([]byte("writer")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	for key, want := range map[string]string{
+		"a/write.txt":   "write",
+		"a/missing.txt": "missing",
+		"b/stream.txt":  "stream",
+		"b/writer.txt":  "writer",
+	} {
+		data, err := localFS.Read(ctx, key)
+		if err != nil {
+			t.Fatalf("Read of %s failed: %v", key, err)
+		}
+		if string(data) != want {
+			t.Fatalf("Read of %s = %q, want %q", key, data, want)
+		}
+	}
+}
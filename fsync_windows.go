@@ -0,0 +1,9 @@
+//go:build windows
+
+package blob
+
+// Does nothing, as Windows cannot sync directories and NTFS journals renames
+// itself.
+func syncDir(path string) error {
+	return nil
+}
@@ -0,0 +1,81 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestLocalFiles_IfGenerationMatch(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_if_generation_match"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	key := "orders/1.json"
+	missing := int64(0)
+	if err := localFS.WriteWithOptions(ctx, key, []byte("v1"), blob.WriteOptions{IfGenerationMatch: &missing}); err != nil {
+		t.Fatalf("WriteWithOptions of missing key failed: %v", err)
+	}
+	// A retry of the first write fails, as the blob exists by now.
+	if err := localFS.WriteWithOptions(ctx, key, []byte("v1"), blob.WriteOptions{IfGenerationMatch: &missing}); !errors.Is(err, blob.ErrPreconditionFailed) {
+		t.Fatalf("Retried create should return ErrPreconditionFailed, got: %v", err)
+	}
+
+	gen, err := localFS.Generation(ctx, key)
+	if err != nil || gen == 0 {
+		t.Fatalf("Generation = %d, %v", gen, err)
+	}
+	if err := localFS.WriteWithOptions(ctx, key, []byte("v2"), blob.WriteOptions{IfGenerationMatch: &gen}); err != nil {
+		t.Fatalf("WriteWithOptions with current generation failed: %v", err)
+	}
+	if err := localFS.WriteWithOptions(ctx, key, []byte("v3"), blob.WriteOptions{IfGenerationMatch: &gen}); !errors.Is(err, blob.ErrPreconditionFailed) {
+		t.Fatalf("WriteWithOptions with stale generation should return ErrPreconditionFailed, got: %v", err)
+	}
+	if data, _ := localFS.Read(ctx, key); string(data) != "v2" {
+		t.Fatalf("Read = %q, want v2", data)
+	}
+}
+
+func TestGcsBucket_ReadAtLeastGeneration(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGcs(t, "test-bucket")
+	gcs := fake.storage(t, "")
+	gen := fake.put("orders/1.json", []byte("v1"), nil).generation
+
+	data, err := gcs.ReadAtLeastGeneration(ctx, "orders/1.json", gen)
+	if err != nil || string(data) != "v1" {
+		t.Fatalf("ReadAtLeastGeneration = %q, %v", data, err)
+	}
+
+	// A read lagging behind the write is retried until it catches up.
+	reads := 0
+	fake.intercept = func(r *http.Request) int {
+		if !strings.HasPrefix(r.URL.Path, "/storage/") && !strings.HasPrefix(r.URL.Path, "/upload/") {
+			if reads++; reads == 2 {
+				fake.put("orders/1.json", []byte("v2"), nil)
+			}
+		}
+		return 0
+	}
+	data, err = gcs.ReadAtLeastGeneration(ctx, "orders/1.json", gen+1)
+	if err != nil || string(data) != "v2" {
+		t.Fatalf("ReadAtLeastGeneration of a lagging blob = %q, %v", data, err)
+	}
+	if reads != 2 {
+		t.Fatalf("Expected two reads, got %d", reads)
+	}
+
+	fake.intercept = nil
+	if _, err := gcs.ReadAtLeastGeneration(ctx, "orders/1.json", gen+10); !errors.Is(err, blob.ErrPreconditionFailed) {
+		t.Fatalf("ReadAtLeastGeneration of a newer generation should return ErrPreconditionFailed, got: %v", err)
+	}
+	if _, err := gcs.ReadAtLeastGeneration(ctx, "orders/missing.json", 1); !errors.Is(err, blob.ErrPreconditionFailed) {
+		t.Fatalf("ReadAtLeastGeneration of a missing blob should return ErrPreconditionFailed, got: %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Returned when writing to or removing from a read-only storage.
+var ErrReadOnly = errors.New("blob: read-only storage")
+
+// Implements the Storage interface for reading blobs from an HTTP server or
+// CDN, such as a read-only mirror. Writes and removes fail with ErrReadOnly.
+type HTTP struct {
+	baseURL string
+	client  *http.Client
+}
+
+// Returns a new HTTP storage reading the blob at key from baseURL/key. A nil
+// client uses http.DefaultClient, which follows redirects.
+func NewHTTPStorage(baseURL string, client *http.Client) *HTTP {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTP{baseURL: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+// Reads a blob with a GET request.
+func (h *HTTP) Read(ctx context.Context, key string) (_ []byte, err error) {
+	defer annotate(&err, "http", "Read", key)
+	resp, err := h.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading body: %w", err)
+	}
+	return data, nil
+}
+
+// Fails with ErrReadOnly.
+func (h *HTTP) Write(ctx context.Context, key string, data []byte) (err error) {
+	defer annotate(&err, "http", "Write", key)
+	return ErrReadOnly
+}
+
+// Fails with ErrReadOnly.
+func (h *HTTP) WriteIfMissing(ctx context.Context, key string, data []byte) (err error) {
+	defer annotate(&err, "http", "WriteIfMissing", key)
+	return ErrReadOnly
+}
+
+// Fails with ErrReadOnly.
+func (h *HTTP) Remove(ctx context.Context, key string) (err error) {
+	defer annotate(&err, "http", "Remove", key)
+	return ErrReadOnly
+}
+
+// Fails with ErrReadOnly.
+func (h *HTTP) RemoveFolder(ctx context.Context, folder string) (err error) {
+	defer annotate(&err, "http", "RemoveFolder", folder)
+	return ErrReadOnly
+}
+
+// Fails with errors.ErrUnsupported, as HTTP has no standard way of listing.
+func (h *HTTP) List(ctx context.Context, prefix string) (_ []string, err error) {
+	defer annotate(&err, "http", "List", prefix)
+	return nil, fmt.Errorf("listing over HTTP: %w", errors.ErrUnsupported)
+}
+
+// Returns an io readerCloser streaming the body of a GET request.
+func (h *HTTP) Reader(ctx context.Context, key string) (_ io.ReadCloser, err error) {
+	defer annotate(&err, "http", "Reader", key)
+	resp, err := h.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Streams the body of a GET request. The caller must close the returned reader.
+func (h *HTTP) ReadStream(ctx context.Context, key string) (_ io.ReadCloser, err error) {
+	defer annotate(&err, "http", "ReadStream", key)
+	return h.Reader(ctx, key)
+}
+
+// Fails with ErrReadOnly.
+func (h *HTTP) Writer(ctx context.Context, key string) (_ io.WriteCloser, err error) {
+	defer annotate(&err, "http", "Writer", key)
+	return nil, ErrReadOnly
+}
+
+// Fails with ErrReadOnly.
+func (h *HTTP) WriteStream(ctx context.Context, key string, r io.Reader) (err error) {
+	defer annotate(&err, "http", "WriteStream", key)
+	return ErrReadOnly
+}
+
+// Reports whether a blob exists with a HEAD request.
+func (h *HTTP) Exists(ctx context.Context, key string) (bool, error) {
+	resp, err := h.do(ctx, http.MethodHead, key, nil)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// Copies the blob at the given key to w and returns the number of bytes written.
+func (h *HTTP) ReadTo(ctx context.Context, key string, w io.Writer) (int64, error) {
+	resp, err := h.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return n, fmt.Errorf("copying: %w", err)
+	}
+	return n, nil
+}
+
+// Copies length bytes of the blob at the given key, starting at offset, to w
+// with a Range request and returns the number of bytes written. A negative
+// length copies until the end of the blob. Fewer bytes are copied if the blob
+// ends before the range. Servers ignoring the Range header are supported by
+// skipping to the range client-side.
+func (h *HTTP) ReadRangeTo(ctx context.Context, key string, offset, length int64, w io.Writer) (int64, error) {
+	if length == 0 {
+		return 0, nil
+	}
+	rng := fmt.Sprintf("bytes=%d-", offset)
+	if length > 0 {
+		rng += fmt.Sprint(offset + length - 1)
+	}
+	resp, err := h.do(ctx, http.MethodGet, key, http.Header{"Range": {rng}})
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) && statusErr.code == http.StatusRequestedRangeNotSatisfiable {
+		return 0, nil // The blob ends before the range
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body := io.Reader(resp.Body)
+	if resp.StatusCode != http.StatusPartialContent {
+		if _, err := io.CopyN(io.Discard, body, offset); err != nil {
+			if err == io.EOF {
+				return 0, nil
+			}
+			return 0, fmt.Errorf("skipping to offset: %w", err)
+		}
+	}
+	if length > 0 {
+		body = io.LimitReader(body, length)
+	}
+	n, err := io.Copy(w, body)
+	if err != nil {
+		return n, fmt.Errorf("copying: %w", err)
+	}
+	return n, nil
+}
+
+// Returned for unsuccessful HTTP responses.
+type httpStatusError struct {
+	method string
+	url    string
+	code   int
+	status string
+	body   string
+}
+
+func (e *httpStatusError) Error() string {
+	if e.body == "" {
+		return fmt.Sprintf("%s %s: %s", e.method, e.url, e.status)
+	}
+	return fmt.Sprintf("%s %s: %s: %s", e.method, e.url, e.status, e.body)
+}
+
+// Sends a request for the blob at key. Unsuccessful responses are returned as
+// errors, matching ErrNotFound for 404s.
+func (h *HTTP) do(ctx context.Context, method, key string, header http.Header) (*http.Response, error) {
+	u := h.url(key)
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	statusErr := &httpStatusError{method: method, url: u, code: resp.StatusCode, status: resp.Status, body: strings.TrimSpace(string(msg))}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %w", ErrNotFound, statusErr)
+	}
+	return nil, statusErr
+}
+
+// Returns the URL of a key, escaping each of its components.
+func (h *HTTP) url(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return h.baseURL + "/" + strings.Join(parts, "/")
+}
@@ -0,0 +1,48 @@
+package blob_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestHTTP(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_http"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := localFS.Write(ctx, "assets/app v1.js", []byte("0123456789")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	server := httptest.NewServer(http.FileServer(http.Dir(basePath)))
+	defer server.Close()
+
+	s := blob.NewHTTPStorage(server.URL, nil)
+	data, err := s.Read(ctx, "assets/app v1.js")
+	if err != nil || string(data) != "0123456789" {
+		t.Fatalf("Read = %q, %v", data, err)
+	}
+	if _, err := s.Read(ctx, "assets/missing.js"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Read of missing key should return ErrNotFound, got: %v", err)
+	}
+	exists, err := s.Exists(ctx, "assets/missing.js")
+	if err != nil || exists {
+		t.Fatalf("Exists of missing key = %v, %v", exists, err)
+	}
+
+	var buf bytes.Buffer
+	n, err := s.ReadRangeTo(ctx, "assets/app v1.js", 2, 3, &buf)
+	if err != nil || n != 3 || buf.String() != "234" {
+		t.Fatalf("ReadRangeTo = %q, %d, %v", buf.String(), n, err)
+	}
+	if err := s.Write(ctx, "assets/new.js", nil); !errors.Is(err, blob.ErrReadOnly) {
+		t.Fatalf("Write should return ErrReadOnly, got: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"cloud.google.com/go/storage"
+)
+
+// Writes a blob only if it still has the given etag, failing with
+// ErrPreconditionFailed if it changed or no longer exists, for optimistic
+// concurrency with the ETag returned by Stat. A numeric etag is taken as a
+// generation from Generation instead.
+//
+// The local file system has no conditional writes, so the check and the
+// write are not atomic and a concurrent write in between is lost. See
+// Version for when the ETag changes.
+func (l *Fs) WriteIfMatch(ctx context.Context, key string, data []byte, etag string) (err error) {
+	defer annotate(&err, "fs", "WriteIfMatch", key)
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if gen, ok := parseGeneration(etag); ok {
+		return l.writeIfGeneration(ctx, key, data, l.newMeta(data), gen)
+	}
+	current, err := l.Version(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%w: %s does not exist", ErrPreconditionFailed, key)
+	}
+	if err != nil {
+		return err
+	}
+	if current != etag {
+		return fmt.Errorf("%w: %s has ETag %s, want %s", ErrPreconditionFailed, key, current, etag)
+	}
+	return l.write(key, data, l.newMeta(data))
+}
+
+// Writes a blob only if it still has the given etag, failing with
+// ErrPreconditionFailed if it changed or no longer exists, for optimistic
+// concurrency with the ETag returned by Stat. A numeric etag is taken as a
+// generation from Generation instead.
+//
+// The write is conditioned on the generation of the object, so it is atomic.
+// An ETag is first resolved to the generation it belongs to, which costs an
+// additional request.
+func (g *Gcs) WriteIfMatch(ctx context.Context, key string, data []byte, etag string) (err error) {
+	defer annotate(&err, "gcs", "WriteIfMatch", key)
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	gen, ok := parseGeneration(etag)
+	if !ok {
+		name, err := g.objectName(key)
+		if err != nil {
+			return err
+		}
+		attrs, err := g.bucket.Object(name).Attrs(ctx)
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return fmt.Errorf("%w: %s does not exist", ErrPreconditionFailed, key)
+		}
+		if err != nil {
+			return fmt.Errorf("getting attributes: %w", err)
+		}
+		if attrs.Etag != etag {
+			return fmt.Errorf("%w: %s has ETag %s, want %s", ErrPreconditionFailed, key, attrs.Etag, etag)
+		}
+		gen = attrs.Generation
+	}
+	return g.WriteWithOptions(ctx, key, data, WriteOptions{IfGenerationMatch: &gen})
+}
+
+// Parses a positive generation passed in place of an ETag.
+func parseGeneration(etag string) (int64, bool) {
+	gen, err := strconv.ParseInt(etag, 10, 64)
+	return gen, err == nil && gen > 0
+}
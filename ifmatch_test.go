@@ -0,0 +1,49 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestLocalFiles_WriteIfMatch(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_write_if_match"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := localFS.Write(ctx, "counter", []byte("1")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	info, err := localFS.Stat(ctx, "counter")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond) // Let the modification time move on
+	if err := localFS.WriteIfMatch(ctx, "counter", []byte("2"), info.ETag); err != nil {
+		t.Fatalf("WriteIfMatch with the current ETag failed: %v", err)
+	}
+	// The ETag read before the first update is stale now.
+	if err := localFS.WriteIfMatch(ctx, "counter", []byte("3"), info.ETag); !errors.Is(err, blob.ErrPreconditionFailed) {
+		t.Fatalf("WriteIfMatch with a stale ETag should return ErrPreconditionFailed, got: %v", err)
+	}
+	if data, _ := localFS.Read(ctx, "counter"); string(data) != "2" {
+		t.Fatalf("Expected 2 after the stale write, got %q", data)
+	}
+	if err := localFS.WriteIfMatch(ctx, "missing", []byte("1"), info.ETag); !errors.Is(err, blob.ErrPreconditionFailed) {
+		t.Fatalf("WriteIfMatch of a missing blob should return ErrPreconditionFailed, got: %v", err)
+	}
+
+	gen, err := localFS.Generation(ctx, "counter")
+	if err != nil {
+		t.Fatalf("Generation failed: %v", err)
+	}
+	if err := localFS.WriteIfMatch(ctx, "counter", []byte("3"), strconv.FormatInt(gen, 10)); err != nil {
+		t.Fatalf("WriteIfMatch with the current generation failed: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package blob_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestLocalFiles_WriteIfMissingOK(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_write_if_missing_ok"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	written, err := localFS.WriteIfMissingOK(ctx, "init.txt", []byte("first"))
+	if err != nil || !written {
+		t.Fatalf("WriteIfMissingOK of a missing blob = %v, %v, want true", written, err)
+	}
+	written, err = localFS.WriteIfMissingOK(ctx, "init.txt", []byte("second"))
+	if err != nil || written {
+		t.Fatalf("WriteIfMissingOK of an existing blob = %v, %v, want false", written, err)
+	}
+	if data, _ := localFS.Read(ctx, "init.txt"); string(data) != "first" {
+		t.Fatalf("Expected first, got %q", data)
+	}
+}
+
+func TestWriteIfMissingOK(t *testing.T) {
+	ctx := context.Background()
+	// Writing the same data twice tells an atomic report from a read-back,
+	// which reports true both times.
+	decorators := map[string]func(s blob.Storage) blob.Storage{
+		"prefixed":     func(s blob.Storage) blob.Storage { return blob.NewPrefixed(s, "tenants/1") },
+		"singleflight": blob.NewSingleflight,
+		"coalescing":   blob.NewWriteCoalescing,
+		"keypolicy":    func(s blob.Storage) blob.Storage { return blob.NewKeyPolicy(s, func(string) error { return nil }) },
+		"keyrewrite": func(s blob.Storage) blob.Storage {
+			return blob.NewKeyRewrite(s, func(k string) string { return "v2/" + k })
+		},
+		"transform": func(s blob.Storage) blob.Storage {
+			return blob.NewTransform(s, func(_ context.Context, _ string, data []byte) ([]byte, error) { return data, nil })
+		},
+		"quota":      func(s blob.Storage) blob.Storage { return blob.NewQuotaEnforced(s, func(string) int64 { return -1 }) },
+		"chunked":    func(s blob.Storage) blob.Storage { return blob.NewChunked(s, 2) },
+		"capture":    func(s blob.Storage) blob.Storage { return blob.NewCapture(s, io.Discard) },
+		"softdelete": func(s blob.Storage) blob.Storage { return blob.NewSoftDelete(s, "trash/", time.Hour) },
+		"async":      func(s blob.Storage) blob.Storage { return blob.NewAsyncStorage(s) },
+		"batch":      func(s blob.Storage) blob.Storage { return blob.NewBatchWriter(s, blob.BatchWriterOptions{}) },
+	}
+	for name, decorate := range decorators {
+		s := decorate(blob.NewMemStorage())
+		for i, want := range []bool{true, false} {
+			written, err := blob.WriteIfMissingOK(ctx, s, "init", []byte("data"))
+			if err != nil || written != want {
+				t.Fatalf("WriteIfMissingOK call %d through %s = %v, %v, want %v", i+1, name, written, err, want)
+			}
+		}
+		if c, ok := s.(io.Closer); ok {
+			c.Close()
+		}
+	}
+}
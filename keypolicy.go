@@ -0,0 +1,139 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Returned when a key violates the key policy of a storage.
+var ErrInvalidKey = errors.New("blob: invalid key")
+
+// Accepts non-empty keys consisting of lower case ASCII letters, digits and
+// the characters "/", "_", "-" and ".", without a leading slash.
+func ValidateAlphanumericKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("%w: empty key", ErrInvalidKey)
+	}
+	if strings.HasPrefix(key, "/") {
+		return fmt.Errorf("%w: %q has a leading slash", ErrInvalidKey, key)
+	}
+	for _, r := range key {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && !strings.ContainsRune("/_-.", r) {
+			return fmt.Errorf("%w: %q contains %q", ErrInvalidKey, key, r)
+		}
+	}
+	return nil
+}
+
+// Rejects keys that could escape their folder or alias other keys when used
+// as paths: absolute keys, backslashes and empty, "." or ".." segments.
+func ValidateNoTraversal(key string) error {
+	if strings.ContainsRune(key, '\\') {
+		return fmt.Errorf("%w: %q contains a backslash", ErrInvalidKey, key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		switch segment {
+		case "":
+			return fmt.Errorf("%w: %q has an empty segment", ErrInvalidKey, key)
+		case ".", "..":
+			return fmt.Errorf("%w: %q has a %q segment", ErrInvalidKey, key, segment)
+		}
+	}
+	return nil
+}
+
+// Validates keys before they reach the backend.
+type keyPolicy struct {
+	Storage
+	validate func(key string) error
+}
+
+// Wraps s so that the keys of all operations are checked with validate and
+// the validation error is returned without touching s. A nil validate applies
+// both ValidateNoTraversal and ValidateAlphanumericKey. List prefixes are not
+// validated, and listed keys are assumed to be valid and passed through.
+func NewKeyPolicy(s Storage, validate func(key string) error) Storage {
+	if validate == nil {
+		validate = func(key string) error {
+			if err := ValidateNoTraversal(key); err != nil {
+				return err
+			}
+			return ValidateAlphanumericKey(key)
+		}
+	}
+	return &keyPolicy{Storage: s, validate: validate}
+}
+
+func (p *keyPolicy) Read(ctx context.Context, key string) ([]byte, error) {
+	if err := p.validate(key); err != nil {
+		return nil, err
+	}
+	return p.Storage.Read(ctx, key)
+}
+
+func (p *keyPolicy) Write(ctx context.Context, key string, data []byte) error {
+	if err := p.validate(key); err != nil {
+		return err
+	}
+	return p.Storage.Write(ctx, key, data)
+}
+
+func (p *keyPolicy) WriteIfMissing(ctx context.Context, key string, data []byte) error {
+	if err := p.validate(key); err != nil {
+		return err
+	}
+	return p.Storage.WriteIfMissing(ctx, key, data)
+}
+
+// Keeps WriteNext atomic on storages that report whether they wrote.
+func (p *keyPolicy) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	if err := p.validate(key); err != nil {
+		return false, err
+	}
+	return writeIfMissing(ctx, p.Storage, key, data)
+}
+
+func (p *keyPolicy) Remove(ctx context.Context, key string) error {
+	if err := p.validate(key); err != nil {
+		return err
+	}
+	return p.Storage.Remove(ctx, key)
+}
+
+func (p *keyPolicy) RemoveFolder(ctx context.Context, folder string) error {
+	if err := p.validate(folder); err != nil {
+		return err
+	}
+	return p.Storage.RemoveFolder(ctx, folder)
+}
+
+func (p *keyPolicy) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := p.validate(key); err != nil {
+		return nil, err
+	}
+	return p.Storage.Reader(ctx, key)
+}
+
+func (p *keyPolicy) ReadStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := p.validate(key); err != nil {
+		return nil, err
+	}
+	return p.Storage.ReadStream(ctx, key)
+}
+
+func (p *keyPolicy) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	if err := p.validate(key); err != nil {
+		return nil, err
+	}
+	return p.Storage.Writer(ctx, key)
+}
+
+func (p *keyPolicy) WriteStream(ctx context.Context, key string, r io.Reader) error {
+	if err := p.validate(key); err != nil {
+		return err
+	}
+	return p.Storage.WriteStream(ctx, key, r)
+}
@@ -0,0 +1,39 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestKeyPolicy(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_key_policy"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	s := blob.NewKeyPolicy(localFS, nil)
+	if err := s.Write(ctx, "users/1/avatar.png", []byte("png")); err != nil {
+		t.Fatalf("Write of valid key failed: %v", err)
+	}
+	for _, key := range []string{"", "/users/1", "Users/1", "users/../secret", "users//1", `users\1`, "users/1 copy"} {
+		if err := s.Write(ctx, key, []byte("x")); !errors.Is(err, blob.ErrInvalidKey) {
+			t.Errorf("Write of %q should return ErrInvalidKey, got: %v", key, err)
+		}
+		if _, err := s.Read(ctx, key); !errors.Is(err, blob.ErrInvalidKey) {
+			t.Errorf("Read of %q should return ErrInvalidKey, got: %v", key, err)
+		}
+	}
+	keys, err := localFS.List(ctx, "")
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("Expected only the valid key to reach the backend, got %v, %v", keys, err)
+	}
+
+	custom := blob.NewKeyPolicy(localFS, blob.ValidateNoTraversal)
+	if err := custom.Write(ctx, "Users/1", []byte("x")); err != nil {
+		t.Fatalf("Write with custom validator failed: %v", err)
+	}
+}
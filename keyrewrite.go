@@ -0,0 +1,99 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Configures NewKeyRewrite.
+type KeyRewriteOption func(*keyRewrite)
+
+// Maps listed backend keys back to the caller's scheme, which List requires.
+// inverse must undo the rewrite: inverse(rewrite(key)) == key for every key.
+func WithKeyInverse(inverse func(key string) string) KeyRewriteOption {
+	return func(r *keyRewrite) {
+		r.inverse = inverse
+	}
+}
+
+// Maps keys before they reach the backend.
+type keyRewrite struct {
+	Storage
+	rewrite func(key string) string
+	inverse func(key string) string
+}
+
+// Wraps s so that every key is passed through rewrite on its way to s, such
+// as to keep reading and writing an old key layout while callers already use
+// the new one. Folders and list prefixes are rewritten too, so rewrite must
+// map a prefix to a prefix of the rewritten keys it covers: rewriting
+// "users/" to "u/" works for "users/<id>" to "u/<id>".
+//
+// Listing needs the mapping to be invertible, so listed keys can be mapped
+// back to the caller's scheme: List fails with errors.ErrUnsupported unless
+// the inverse is given with WithKeyInverse.
+func NewKeyRewrite(s Storage, rewrite func(key string) string, opts ...KeyRewriteOption) Storage {
+	r := &keyRewrite{Storage: s, rewrite: rewrite}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *keyRewrite) Read(ctx context.Context, key string) ([]byte, error) {
+	return r.Storage.Read(ctx, r.rewrite(key))
+}
+
+func (r *keyRewrite) Write(ctx context.Context, key string, data []byte) error {
+	return r.Storage.Write(ctx, r.rewrite(key), data)
+}
+
+func (r *keyRewrite) WriteIfMissing(ctx context.Context, key string, data []byte) error {
+	return r.Storage.WriteIfMissing(ctx, r.rewrite(key), data)
+}
+
+// Keeps WriteNext atomic on storages that report whether they wrote.
+func (r *keyRewrite) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	return writeIfMissing(ctx, r.Storage, r.rewrite(key), data)
+}
+
+func (r *keyRewrite) Remove(ctx context.Context, key string) error {
+	return r.Storage.Remove(ctx, r.rewrite(key))
+}
+
+func (r *keyRewrite) RemoveFolder(ctx context.Context, folder string) error {
+	return r.Storage.RemoveFolder(ctx, r.rewrite(folder))
+}
+
+// Lists the rewritten prefix and maps the keys back with the inverse.
+func (r *keyRewrite) List(ctx context.Context, prefix string) ([]string, error) {
+	if r.inverse == nil {
+		return nil, fmt.Errorf("listing rewritten keys without an inverse: %w", errors.ErrUnsupported)
+	}
+	keys, err := r.Storage.List(ctx, r.rewrite(prefix))
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		keys[i] = r.inverse(key)
+	}
+	return keys, nil
+}
+
+func (r *keyRewrite) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	return r.Storage.Reader(ctx, r.rewrite(key))
+}
+
+func (r *keyRewrite) ReadStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	return r.Storage.ReadStream(ctx, r.rewrite(key))
+}
+
+func (r *keyRewrite) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	return r.Storage.Writer(ctx, r.rewrite(key))
+}
+
+func (r *keyRewrite) WriteStream(ctx context.Context, key string, rd io.Reader) error {
+	return r.Storage.WriteStream(ctx, r.rewrite(key), rd)
+}
@@ -0,0 +1,54 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestKeyRewrite(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_key_rewrite"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	toOld := func(key string) string {
+		if rest, ok := strings.CutPrefix(key, "users/"); ok {
+			return "u/" + rest
+		}
+		return key
+	}
+	toNew := func(key string) string {
+		if rest, ok := strings.CutPrefix(key, "u/"); ok {
+			return "users/" + rest
+		}
+		return key
+	}
+	s := blob.NewKeyRewrite(localFS, toOld, blob.WithKeyInverse(toNew))
+
+	if err := s.Write(ctx, "users/1/profile.json", []byte("{}")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if data, err := localFS.Read(ctx, "u/1/profile.json"); err != nil || string(data) != "{}" {
+		t.Fatalf("Expected write to the old layout, got %q, %v", data, err)
+	}
+	if data, err := s.Read(ctx, "users/1/profile.json"); err != nil || string(data) != "{}" {
+		t.Fatalf("Read = %q, %v", data, err)
+	}
+	keys, err := s.List(ctx, "users/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"users/1/profile.json"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("List = %v, want %v", keys, want)
+	}
+
+	if _, err := blob.NewKeyRewrite(localFS, toOld).List(ctx, "users/"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("List without inverse should return ErrUnsupported, got: %v", err)
+	}
+}
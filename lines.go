@@ -0,0 +1,74 @@
+package blob
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Default maximum length of a single line read by StreamLines.
+const DefaultMaxLineSize = 1024 * 1024
+
+// Configures StreamLines and ReadFirstLine.
+type LineOption func(*lineOptions)
+
+type lineOptions struct {
+	maxLineSize int
+}
+
+// Sets the maximum length of a single line, including the newline.
+func WithMaxLineSize(n int) LineOption {
+	return func(o *lineOptions) {
+		o.maxLineSize = n
+	}
+}
+
+// Streams a blob line by line, calling fn for every line without its
+// trailing newline. The line slice is only valid until fn returns.
+// Streaming stops at the first error returned by fn.
+func StreamLines(ctx context.Context, s Storage, key string, fn func(line []byte) error, opts ...LineOption) error {
+	o := lineOptions{maxLineSize: DefaultMaxLineSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	rc, err := s.ReadStream(ctx, key)
+	if err != nil {
+		return fmt.Errorf("opening stream: %w", err)
+	}
+	defer rc.Close()
+
+	scanner := bufio.NewScanner(rc)
+	scanner.Buffer(make([]byte, 0, min(o.maxLineSize, 64*1024)), o.maxLineSize)
+	for scanner.Scan() {
+		if err := fn(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return fmt.Errorf("line exceeds %d bytes, use WithMaxLineSize to allow longer lines: %w", o.maxLineSize, err)
+		}
+		return fmt.Errorf("scanning lines: %w", err)
+	}
+	return nil
+}
+
+// Stops StreamLines after the first line.
+var errFirstLine = errors.New("first line read")
+
+// Reads the first line of a blob without its trailing newline, only
+// downloading as much of the blob as needed to find it. Returns the whole blob
+// if it contains no newline, and ErrNotFound if it does not exist.
+func ReadFirstLine(ctx context.Context, s Storage, key string, opts ...LineOption) ([]byte, error) {
+	line := []byte{}
+	err := StreamLines(ctx, s, key, func(l []byte) error {
+		line = append(line, l...)
+		return errFirstLine
+	}, opts...)
+	if err != nil && !errors.Is(err, errFirstLine) {
+		return nil, wrapNotFound(err)
+	}
+	return line, nil
+}
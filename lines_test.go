@@ -0,0 +1,79 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestStreamLines(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_stream_lines"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	key := "data/rows.csv"
+	err := localFS.Write(ctx, key, []byte("a,b\n1,2\n3,4"))
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var lines []string
+	err = blob.StreamLines(ctx, localFS, key, func(line []byte) error {
+		lines = append(lines, string(line))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamLines failed: %v", err)
+	}
+	if len(lines) != 3 || lines[0] != "a,b" || lines[2] != "3,4" {
+		t.Fatalf("Unexpected lines: %q", lines)
+	}
+
+	// Errors from fn stop streaming
+	stop := errors.New("stop")
+	count := 0
+	err = blob.StreamLines(ctx, localFS, key, func(line []byte) error {
+		count++
+		return stop
+	})
+	if !errors.Is(err, stop) || count != 1 {
+		t.Fatalf("Expected streaming to stop after first line, got count %d and err %v", count, err)
+	}
+
+	// Lines longer than the max size fail
+	err = blob.StreamLines(ctx, localFS, key, func(line []byte) error { return nil }, blob.WithMaxLineSize(2))
+	if err == nil {
+		t.Fatalf("Expected error for line exceeding max size")
+	}
+}
+
+func TestReadFirstLine(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_read_first_line"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := localFS.Write(ctx, "data/rows.csv", []byte("a,b\n1,2\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := localFS.Write(ctx, "data/single.txt", []byte("v2")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	line, err := blob.ReadFirstLine(ctx, localFS, "data/rows.csv")
+	if err != nil || string(line) != "a,b" {
+		t.Fatalf("ReadFirstLine = %q, %v, want a,b", line, err)
+	}
+	line, err = blob.ReadFirstLine(ctx, localFS, "data/single.txt")
+	if err != nil || string(line) != "v2" {
+		t.Fatalf("ReadFirstLine without newline = %q, %v, want v2", line, err)
+	}
+	_, err = blob.ReadFirstLine(ctx, localFS, "data/missing.txt")
+	if !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("ReadFirstLine of missing key should return ErrNotFound, got: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// Page size of ListDirPage when none is given.
+const DefaultListPageSize = 1000
+
+// Lists one page of the immediate children of a prefix, for browsing folders
+// page by page. dirs holds the keys of child folders ending with "/", files
+// the keys of child blobs. Pass the returned nextToken to get the next page,
+// which is empty after the last page. A pageSize of zero or less uses
+// DefaultListPageSize.
+func (g *Gcs) ListDirPage(ctx context.Context, prefix, pageToken string, pageSize int) (dirs, files []string, nextToken string, err error) {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	if pageSize <= 0 {
+		pageSize = DefaultListPageSize
+	}
+	query := &storage.Query{Prefix: g.fullPrefix(prefix), Delimiter: "/"}
+	if err := query.SetAttrSelection([]string{"Name"}); err != nil {
+		return nil, nil, "", fmt.Errorf("selecting attributes: %w", err)
+	}
+	var page []*storage.ObjectAttrs
+	pager := iterator.NewPager(g.bucket.Objects(ctx, query), pageSize, pageToken)
+	nextToken, err = pager.NextPage(&page)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("listing page: %w", err)
+	}
+	for _, attrs := range page {
+		if attrs.Prefix != "" {
+			dirs = append(dirs, g.relKey(attrs.Prefix))
+		} else {
+			files = append(files, g.relKey(attrs.Name))
+		}
+	}
+	return dirs, files, nextToken, nil
+}
+
+// Lists one page of the immediate children of a prefix, for browsing folders
+// page by page. dirs holds the keys of child folders ending with "/", files
+// the keys of child blobs. Pass the returned nextToken to get the next page,
+// which is empty after the last page. A pageSize of zero or less uses
+// DefaultListPageSize.
+//
+// Children are sorted by key and the token is the last key of the page, so
+// pages stay stable while children are added or removed.
+func (l *Fs) ListDirPage(ctx context.Context, prefix, pageToken string, pageSize int) (dirs, files []string, nextToken string, err error) {
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if pageSize <= 0 {
+		pageSize = DefaultListPageSize
+	}
+	dir := prefix[:strings.LastIndex(prefix, "/")+1]
+	root := l.basePath
+	if dir != "" {
+		if root, err = l.filePath(strings.TrimSuffix(dir, "/")); err != nil {
+			return nil, nil, "", err
+		}
+	}
+	entries, err := os.ReadDir(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, "", nil
+	}
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("reading directory: %w", err)
+	}
+
+	// GCS orders folders by their prefix including the trailing slash.
+	var keys []string
+	for _, entry := range entries {
+		if dir == "" && entry.Name() == fsMetaDir {
+			continue // Metadata sidecars are not blobs
+		}
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), fsTempPrefix) {
+			continue // Temporary file of a write
+		}
+		key := path.Join(dir, l.decodeKey(entry.Name()))
+		if entry.IsDir() {
+			key += "/"
+		}
+		if strings.HasPrefix(key, prefix) && key > pageToken {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	if len(keys) > pageSize {
+		keys = keys[:pageSize]
+		nextToken = keys[pageSize-1]
+	}
+	for _, key := range keys {
+		if strings.HasSuffix(key, "/") {
+			dirs = append(dirs, key)
+		} else {
+			files = append(files, key)
+		}
+	}
+	return dirs, files, nextToken, nil
+}
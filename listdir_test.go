@@ -0,0 +1,49 @@
+package blob_test
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestLocalFiles_ListDirPage(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_list_dir_page"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	for _, key := range []string{"home/a.txt", "home/b/1.txt", "home/c.txt", "home/d/2.txt", "home/e.txt"} {
+		if err := localFS.Write(ctx, key, []byte(key)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	var dirs, files []string
+	token := ""
+	pages := 0
+	for {
+		d, f, next, err := localFS.ListDirPage(ctx, "home/", token, 2)
+		if err != nil {
+			t.Fatalf("ListDirPage failed: %v", err)
+		}
+		dirs = append(dirs, d...)
+		files = append(files, f...)
+		pages++
+		if next == "" {
+			break
+		}
+		token = next
+	}
+	if pages != 3 {
+		t.Fatalf("Expected 3 pages, got %d", pages)
+	}
+	if !slices.Equal(dirs, []string{"home/b/", "home/d/"}) {
+		t.Fatalf("Unexpected dirs: %v", dirs)
+	}
+	if !slices.Equal(files, []string{"home/a.txt", "home/c.txt", "home/e.txt"}) {
+		t.Fatalf("Unexpected files: %v", files)
+	}
+}
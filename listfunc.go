@@ -0,0 +1,99 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// Implemented by storages that can list keys lazily, such as Fs, Gcs and
+// Redis, so listing huge prefixes does not hold all keys in memory.
+type FuncLister interface {
+	// Calls fn with the key of every blob starting with prefix. An error
+	// from fn stops the listing and is returned as is.
+	ListFunc(ctx context.Context, prefix string, fn func(key string) error) error
+}
+
+// Calls fn with the key of every blob of s starting with prefix, listing
+// lazily if s implements FuncLister. Other storages are listed with List
+// first. An error from fn stops the listing and is returned as is.
+func ListFunc(ctx context.Context, s Storage, prefix string, fn func(key string) error) error {
+	if l, ok := s.(FuncLister); ok {
+		return l.ListFunc(ctx, prefix, fn)
+	}
+	keys, err := s.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Calls fn with the key of every blob starting with prefix in key order while
+// walking the directory tree.
+func (l *Fs) ListFunc(ctx context.Context, prefix string, fn func(key string) error) error {
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	return l.walk(ctx, prefix, func(key string, info fs.FileInfo) error {
+		return fn(key)
+	})
+}
+
+// Calls fn with the key of every blob starting with prefix in key order,
+// fetching one page of objects at a time.
+func (g *Gcs) ListFunc(ctx context.Context, prefix string, fn func(key string) error) error {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	query := &storage.Query{Prefix: g.fullPrefix(prefix)}
+	if err := query.SetAttrSelection([]string{"Name"}); err != nil {
+		return fmt.Errorf("selecting attributes: %w", err)
+	}
+	it := g.bucket.Objects(ctx, query)
+	for {
+		objAttrs, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("iterating objects: %w", err)
+		}
+		// Keys of a fetched page are passed on without further requests.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(g.relKey(objAttrs.Name)); err != nil {
+			return err
+		}
+	}
+}
+
+// Calls fn with the key of every blob starting with prefix while scanning
+// the keyspace. Unlike List, keys are passed in no particular order and a key
+// may be passed more than once if the keyspace changes during the scan.
+func (r *Redis) ListFunc(ctx context.Context, prefix string, fn func(key string) error) error {
+	if r.prefix != "" {
+		prefix = r.prefix + "/" + prefix
+	}
+	it := r.client.Scan(ctx, 0, escapeGlob(prefix)+"*", 100).Iterator()
+	for it.Next(ctx) {
+		key := it.Val()
+		if r.prefix != "" {
+			key = strings.TrimPrefix(key, r.prefix+"/")
+		}
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("scanning keys: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestListFunc(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_list_func"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	for _, key := range []string{"logs/b.log", "logs/a.log", "logs/2024/c.log", "other/d.log"} {
+		if err := localFS.Write(ctx, key, []byte(key)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	var keys []string
+	err := blob.ListFunc(ctx, localFS, "logs/", func(key string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ListFunc failed: %v", err)
+	}
+	want := []string{"logs/2024/c.log", "logs/a.log", "logs/b.log"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("ListFunc = %v, want %v", keys, want)
+	}
+
+	// An error from the callback stops the listing right away.
+	errStop := errors.New("stop")
+	calls := 0
+	err = localFS.ListFunc(ctx, "logs/", func(key string) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Fatalf("ListFunc should stop after the first error, got %v after %d calls", err, calls)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = localFS.ListFunc(cancelled, "logs/", func(key string) error { return nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ListFunc with cancelled context should return context.Canceled, got: %v", err)
+	}
+}
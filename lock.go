@@ -0,0 +1,120 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Interval at which WithLock retries acquiring a lock held by another process.
+const lockPollInterval = 10 * time.Millisecond
+
+// Times lockPath reopens a lock file that was removed while it tried to
+// open it, before giving up.
+const lockOpenAttempts = 100
+
+// Prefix of the lock files of WithLock in the reserved metadata folder,
+// followed by the hex encoded SHA256 of the key.
+const fsLockPrefix = ".lock-"
+
+// Runs a read-modify-write of the blob at the given key while holding an
+// exclusive advisory lock on a lock file for the key in the reserved metadata
+// folder, serializing it with WithLock calls of other goroutines and processes
+// sharing the directory. fn receives the current content, which is empty if
+// the blob does not exist yet, and returns the new content. The result is
+// written atomically like Write, so readers that do not lock see either the
+// previous or the new content, and nothing is written if fn fails.
+//
+// The lock is host-local: advisory locks are not reliable on network file
+// systems such as NFS. Plain writes to the same key do not take the lock.
+func (l *Fs) WithLock(ctx context.Context, key string, fn func(current []byte) ([]byte, error)) error {
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	path, err := l.filePath(key)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(l.basePath, fsMetaDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating metadata directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(key))
+	lock := filepath.Join(dir, fsLockPrefix+hex.EncodeToString(sum[:]))
+	f, err := lockPath(ctx, lock)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	defer unlockFile(f)
+	// Removing the lock file while still holding it is safe, as lockPath
+	// retries when the file it locked was removed. A failed removal only
+	// leaves the file for the next call.
+	defer os.Remove(lock)
+
+	current, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("reading: %w", err)
+	}
+	updated, err := fn(current)
+	if err != nil {
+		return err
+	}
+	return l.write(key, updated, l.newMeta(updated))
+}
+
+// Opens and locks the file at path, creating it if missing. A file that was
+// removed while waiting for its lock, by the WithLock holding it, is opened
+// again.
+func lockPath(ctx context.Context, path string) (*os.File, error) {
+	for attempt := 1; ; attempt++ {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+		if os.IsExist(err) {
+			f, err = os.OpenFile(path, os.O_RDWR, 0o644)
+			if os.IsNotExist(err) && attempt < lockOpenAttempts {
+				continue // Removed in between
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("opening lock file: %w", err)
+		}
+		if err := lockFile(ctx, f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("locking file: %w", err)
+		}
+		locked, err := f.Stat()
+		if err != nil {
+			unlockFile(f)
+			f.Close()
+			return nil, fmt.Errorf("stating lock file: %w", err)
+		}
+		if current, err := os.Stat(path); err == nil && os.SameFile(locked, current) {
+			return f, nil
+		}
+		unlockFile(f)
+		f.Close()
+	}
+}
+
+// Acquires an exclusive lock on f, waiting until it is available or ctx is done.
+func lockFile(ctx context.Context, f *os.File) error {
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			return err
+		}
+		if locked {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
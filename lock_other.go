@@ -0,0 +1,17 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package blob
+
+import (
+	"errors"
+	"os"
+)
+
+// File locks are not supported on this platform.
+func tryLockFile(f *os.File) (bool, error) {
+	return false, errors.ErrUnsupported
+}
+
+func unlockFile(f *os.File) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,111 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestLocalFiles_WithLock(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_with_lock"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	key := "counters/visits"
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := localFS.WithLock(ctx, key, func(current []byte) ([]byte, error) {
+				n, _ := strconv.Atoi(string(current))
+				return []byte(strconv.Itoa(n + 1)), nil
+			})
+			if err != nil {
+				t.Errorf("WithLock failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := localFS.Read(ctx, key)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if string(data) != "20" {
+		t.Fatalf("Expected counter 20, got %s", data)
+	}
+}
+
+func TestLocalFiles_WithLockFailing(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_with_lock_failing"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath, blob.WithFsync())
+	errFn := errors.New("fn failed")
+	err := localFS.WithLock(ctx, "missing", func([]byte) ([]byte, error) { return nil, errFn })
+	if !errors.Is(err, errFn) {
+		t.Fatalf("Expected the error of fn, got: %v", err)
+	}
+	if exists, err := localFS.Exists(ctx, "missing"); err != nil || exists {
+		t.Fatalf("Expected no blob after a failing fn, got exists %v, %v", exists, err)
+	}
+
+	if err := localFS.Write(ctx, "existing", []byte("data")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	err = localFS.WithLock(ctx, "existing", func([]byte) ([]byte, error) { return nil, errFn })
+	if !errors.Is(err, errFn) {
+		t.Fatalf("Expected the error of fn, got: %v", err)
+	}
+	if data, err := localFS.Read(ctx, "existing"); err != nil || string(data) != "data" {
+		t.Fatalf("Expected an existing blob to be kept after a failing fn, got %q, %v", data, err)
+	}
+
+	// Locking still works after the file was removed.
+	err = localFS.WithLock(ctx, "missing", func(current []byte) ([]byte, error) { return []byte("created"), nil })
+	if err != nil {
+		t.Fatalf("WithLock failed: %v", err)
+	}
+	if data, _ := localFS.Read(ctx, "missing"); string(data) != "created" {
+		t.Fatalf("Expected created, got %q", data)
+	}
+}
+
+func TestLocalFiles_WithLockReaders(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_with_lock_readers"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := localFS.Write(ctx, "config", []byte("old")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	// Readers that do not lock see the previous content until the result is
+	// written.
+	err := localFS.WithLock(ctx, "config", func(current []byte) ([]byte, error) {
+		if data, err := localFS.Read(ctx, "config"); err != nil || string(data) != "old" {
+			t.Errorf("Read while locked = %q, %v, want old", data, err)
+		}
+		return []byte("new"), nil
+	})
+	if err != nil {
+		t.Fatalf("WithLock failed: %v", err)
+	}
+	if data, _ := localFS.Read(ctx, "config"); string(data) != "new" {
+		t.Fatalf("Expected new, got %q", data)
+	}
+	if keys, err := localFS.List(ctx, ""); err != nil || len(keys) != 1 {
+		t.Fatalf("Expected only the blob to be listed, got %v, %v", keys, err)
+	}
+	if entries, _ := os.ReadDir(basePath + "/.blob-meta"); len(entries) != 1 {
+		t.Fatalf("Expected the lock file to be removed, got %v", entries)
+	}
+}
@@ -0,0 +1,196 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Stores blobs in memory, for tests and other fakes of a Storage. Data is
+// copied on the way in and out, so callers cannot modify stored blobs through
+// their slices.
+type Mem struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+// Creates an empty in-memory storage.
+func NewMemStorage() *Mem {
+	return &Mem{blobs: make(map[string][]byte)}
+}
+
+// Reads a blob from memory.
+func (m *Mem) Read(ctx context.Context, key string) (_ []byte, err error) {
+	defer annotate(&err, "mem", "Read", key)
+	return m.get(key)
+}
+
+// Returns a copy of the blob at key.
+func (m *Mem) get(key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.blobs[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return bytes.Clone(data), nil
+}
+
+// Writes a blob to memory.
+func (m *Mem) Write(ctx context.Context, key string, data []byte) (err error) {
+	defer annotate(&err, "mem", "Write", key)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blobs[key] = bytes.Clone(data)
+	return nil
+}
+
+// Writes a blob to memory if the key does not contain any data yet
+func (m *Mem) WriteIfMissing(ctx context.Context, key string, data []byte) (err error) {
+	defer annotate(&err, "mem", "WriteIfMissing", key)
+	_, err = m.writeIfMissing(ctx, key, data)
+	return err
+}
+
+// Writes a blob to memory if the key does not contain any data yet and
+// reports whether this call created it.
+func (m *Mem) WriteIfMissingOK(ctx context.Context, key string, data []byte) (_ bool, err error) {
+	defer annotate(&err, "mem", "WriteIfMissingOK", key)
+	return m.writeIfMissing(ctx, key, data)
+}
+
+// Writes a blob if the key does not contain any data yet and reports whether
+// it did.
+func (m *Mem) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.blobs[key]; ok {
+		return false, nil
+	}
+	m.blobs[key] = bytes.Clone(data)
+	return true, nil
+}
+
+// Removes a blob from memory.
+func (m *Mem) Remove(ctx context.Context, key string) (err error) {
+	defer annotate(&err, "mem", "Remove", key)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.blobs[key]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	delete(m.blobs, key)
+	return nil
+}
+
+// Removes all blobs in the folder.
+func (m *Mem) RemoveFolder(ctx context.Context, folder string) (err error) {
+	defer annotate(&err, "mem", "RemoveFolder", folder)
+	if err := checkFolder(folder); err != nil {
+		return err
+	}
+	prefix := strings.TrimSuffix(folder, "/") + "/"
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.blobs {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.blobs, key)
+		}
+	}
+	return nil
+}
+
+// Lists the keys of all blobs starting with the given prefix, sorted by key.
+func (m *Mem) List(ctx context.Context, prefix string) (_ []string, err error) {
+	defer annotate(&err, "mem", "List", prefix)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var keys []string
+	for key := range m.blobs {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// Lists all blobs whose key starts with the given prefix, sorted by key. Mem
+// does not track modification times.
+func (m *Mem) ListInfo(ctx context.Context, prefix string) (_ []BlobInfo, err error) {
+	defer annotate(&err, "mem", "ListInfo", prefix)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var infos []BlobInfo
+	for key, data := range m.blobs {
+		if strings.HasPrefix(key, prefix) {
+			infos = append(infos, BlobInfo{Key: key, Size: int64(len(data))})
+		}
+	}
+	slices.SortFunc(infos, func(a, b BlobInfo) int { return strings.Compare(a.Key, b.Key) })
+	return infos, nil
+}
+
+// Reports whether a blob exists at the given key.
+func (m *Mem) Exists(ctx context.Context, key string) (_ bool, err error) {
+	defer annotate(&err, "mem", "Exists", key)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.blobs[key]
+	return ok, nil
+}
+
+// Returns an io readerCloser for a copy of the blob at the given key.
+func (m *Mem) Reader(ctx context.Context, key string) (_ io.ReadCloser, err error) {
+	defer annotate(&err, "mem", "Reader", key)
+	data, err := m.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Returns an io readerCloser for a copy of the blob at the given key.
+func (m *Mem) ReadStream(ctx context.Context, key string) (_ io.ReadCloser, err error) {
+	defer annotate(&err, "mem", "ReadStream", key)
+	data, err := m.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Returns an io writerCloser that stores the written data on Close.
+func (m *Mem) Writer(ctx context.Context, key string) (_ io.WriteCloser, err error) {
+	defer annotate(&err, "mem", "Writer", key)
+	return &memWriter{mem: m, key: key}, nil
+}
+
+// Writes the data read from r to the blob at the given key once complete.
+func (m *Mem) WriteStream(ctx context.Context, key string, r io.Reader) (err error) {
+	defer annotate(&err, "mem", "WriteStream", key)
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("reading data: %w", err)
+	}
+	return m.Write(ctx, key, data)
+}
+
+// Buffers written data until it is closed.
+type memWriter struct {
+	mem *Mem
+	key string
+	buf bytes.Buffer
+}
+
+func (w *memWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *memWriter) Close() error {
+	return w.mem.Write(context.Background(), w.key, w.buf.Bytes())
+}
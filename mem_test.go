@@ -0,0 +1,71 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestMem(t *testing.T) {
+	ctx := context.Background()
+	mem := blob.NewMemStorage()
+
+	data := []byte("hello")
+	if err := mem.Write(ctx, "docs/a.txt", data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	data[0] = 'j' // Must not change the stored blob
+	got, err := mem.Read(ctx, "docs/a.txt")
+	if err != nil || string(got) != "hello" {
+		t.Fatalf("Read = %q, %v, want hello", got, err)
+	}
+	got[0] = 'j'
+	if again, _ := mem.Read(ctx, "docs/a.txt"); string(again) != "hello" {
+		t.Fatalf("Modifying a read result changed the blob to %q", again)
+	}
+	if _, err := mem.Read(ctx, "docs/missing.txt"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Read of missing key should return ErrNotFound, got: %v", err)
+	}
+
+	// Exactly one of many concurrent WriteIfMissing calls wins.
+	var wg sync.WaitGroup
+	var winner atomic.Value
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value := []byte{byte('0' + i)}
+			if err := mem.WriteIfMissing(ctx, "lock", value); err != nil {
+				t.Errorf("WriteIfMissing failed: %v", err)
+			}
+			if got, _ := mem.Read(ctx, "lock"); string(got) == string(value) {
+				winner.Store(string(value))
+			}
+		}()
+	}
+	wg.Wait()
+	if got, _ := mem.Read(ctx, "lock"); winner.Load() != string(got) {
+		t.Fatalf("WriteIfMissing stored %q, winner %v", got, winner.Load())
+	}
+
+	for _, key := range []string{"docs", "docs/b.txt", "docs/sub/c.txt", "docsx/d.txt"} {
+		if err := mem.Write(ctx, key, []byte(key)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := mem.RemoveFolder(ctx, "docs"); err != nil {
+		t.Fatalf("RemoveFolder failed: %v", err)
+	}
+	keys, err := mem.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"docs", "docsx/d.txt", "lock"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("List after RemoveFolder = %v, want %v", keys, want)
+	}
+}
@@ -0,0 +1,35 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+)
+
+// Moves the blob at srcKey in src to dstKey in dst, which may be different
+// storages such as a Gcs bucket and an Fs cache. The blob is streamed rather
+// than buffered and only removed from src once it is fully written to dst.
+//
+// The blob is written with WriteStream, so a failed copy leaves the source
+// untouched and, for Fs and Gcs, no partial blob at dstKey. If removing the
+// source fails after the write succeeded, the blob exists in both storages and
+// the returned error says so. Moving a blob onto its own key in the same
+// storage does nothing.
+func MoveAcross(ctx context.Context, src Storage, srcKey string, dst Storage, dstKey string) error {
+	if srcKey == dstKey && src == dst {
+		return nil
+	}
+	rc, err := src.Reader(ctx, srcKey)
+	if err != nil {
+		return fmt.Errorf("opening source: %w", err)
+	}
+	defer rc.Close()
+
+	if err := dst.WriteStream(ctx, dstKey, rc); err != nil {
+		return fmt.Errorf("writing destination: %w", err)
+	}
+
+	if err := src.Remove(ctx, srcKey); err != nil {
+		return fmt.Errorf("removing source after copying to destination, blob exists in both: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package blob_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestMoveAcross(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_move_across"
+	defer os.RemoveAll(basePath)
+
+	src := blob.NewFsStorage(basePath + "/src")
+	dst := blob.NewFsStorage(basePath + "/dst")
+	if err := src.Write(ctx, "reports/q1.csv", []byte("a,b")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := blob.MoveAcross(ctx, src, "reports/q1.csv", dst, "cache/q1.csv"); err != nil {
+		t.Fatalf("MoveAcross failed: %v", err)
+	}
+	data, err := dst.Read(ctx, "cache/q1.csv")
+	if err != nil || string(data) != "a,b" {
+		t.Fatalf("Read of moved blob = %q, %v", data, err)
+	}
+	if exists, _ := src.Exists(ctx, "reports/q1.csv"); exists {
+		t.Fatalf("Source blob still exists after move")
+	}
+}
+
+func TestMoveAcross_SameKey(t *testing.T) {
+	ctx := context.Background()
+	m := blob.NewMemStorage()
+	if err := m.Write(ctx, "k", []byte("data")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := blob.MoveAcross(ctx, m, "k", m, "k"); err != nil {
+		t.Fatalf("MoveAcross onto the same key failed: %v", err)
+	}
+	if data, err := m.Read(ctx, "k"); err != nil || string(data) != "data" {
+		t.Fatalf("Read after MoveAcross onto the same key = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,198 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Receives the outcome of every operation of a storage wrapped with
+// NewObserved, such as to record metrics. It is called synchronously after
+// each operation, so it should be fast and must be safe for concurrent use.
+type Observer interface {
+	// Reports an operation, named like the Storage method such as "Read",
+	// with its key, folder or prefix, the bytes read or written, its
+	// duration and its error, or nil if it succeeded.
+	OnOp(op string, key string, bytes int, dur time.Duration, err error)
+}
+
+// Adapts a function to an Observer.
+type ObserverFunc func(op string, key string, bytes int, dur time.Duration, err error)
+
+func (f ObserverFunc) OnOp(op string, key string, bytes int, dur time.Duration, err error) {
+	f(op, key, bytes, dur, err)
+}
+
+// Reports every operation to an observer.
+type observed struct {
+	Storage
+	o Observer
+}
+
+// Wraps s so that every operation is reported to o with its duration, bytes
+// and error, such as to export latencies and error rates to Prometheus or
+// OpenTelemetry without instrumenting each backend. Readers and writers are
+// reported once closed, with the time since they were opened. A nil o
+// returns s unwrapped, so instrumentation can be left in place at no cost.
+func NewObserved(s Storage, o Observer) Storage {
+	if o == nil {
+		return s
+	}
+	return &observed{Storage: s, o: o}
+}
+
+func (s *observed) Read(ctx context.Context, key string) ([]byte, error) {
+	start := time.Now()
+	data, err := s.Storage.Read(ctx, key)
+	s.o.OnOp("Read", key, len(data), time.Since(start), err)
+	return data, err
+}
+
+func (s *observed) Write(ctx context.Context, key string, data []byte) error {
+	start := time.Now()
+	err := s.Storage.Write(ctx, key, data)
+	s.o.OnOp("Write", key, len(data), time.Since(start), err)
+	return err
+}
+
+func (s *observed) WriteIfMissing(ctx context.Context, key string, data []byte) error {
+	start := time.Now()
+	err := s.Storage.WriteIfMissing(ctx, key, data)
+	s.o.OnOp("WriteIfMissing", key, len(data), time.Since(start), err)
+	return err
+}
+
+// Keeps WriteNext atomic on storages that report whether they wrote.
+func (s *observed) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	start := time.Now()
+	written, err := writeIfMissing(ctx, s.Storage, key, data)
+	s.o.OnOp("WriteIfMissing", key, len(data), time.Since(start), err)
+	return written, err
+}
+
+func (s *observed) Remove(ctx context.Context, key string) error {
+	start := time.Now()
+	err := s.Storage.Remove(ctx, key)
+	s.o.OnOp("Remove", key, 0, time.Since(start), err)
+	return err
+}
+
+func (s *observed) RemoveFolder(ctx context.Context, folder string) error {
+	start := time.Now()
+	err := s.Storage.RemoveFolder(ctx, folder)
+	s.o.OnOp("RemoveFolder", folder, 0, time.Since(start), err)
+	return err
+}
+
+func (s *observed) List(ctx context.Context, prefix string) ([]string, error) {
+	start := time.Now()
+	keys, err := s.Storage.List(ctx, prefix)
+	s.o.OnOp("List", prefix, 0, time.Since(start), err)
+	return keys, err
+}
+
+func (s *observed) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.reader("Reader", key, time.Now(), func() (io.ReadCloser, error) { return s.Storage.Reader(ctx, key) })
+}
+
+func (s *observed) ReadStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.reader("ReadStream", key, time.Now(), func() (io.ReadCloser, error) { return s.Storage.ReadStream(ctx, key) })
+}
+
+// Opens a reader with open that reports op once closed, or right away if
+// opening fails.
+func (s *observed) reader(op, key string, start time.Time, open func() (io.ReadCloser, error)) (io.ReadCloser, error) {
+	rc, err := open()
+	if err != nil {
+		s.o.OnOp(op, key, 0, time.Since(start), err)
+		return nil, err
+	}
+	return &observedReader{ReadCloser: rc, s: s, op: op, key: key, start: start}, nil
+}
+
+func (s *observed) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	start := time.Now()
+	wc, err := s.Storage.Writer(ctx, key)
+	if err != nil {
+		s.o.OnOp("Writer", key, 0, time.Since(start), err)
+		return nil, err
+	}
+	return &observedWriter{WriteCloser: wc, s: s, key: key, start: start}, nil
+}
+
+func (s *observed) WriteStream(ctx context.Context, key string, r io.Reader) error {
+	start := time.Now()
+	cr := &countingReader{Reader: r}
+	err := s.Storage.WriteStream(ctx, key, cr)
+	s.o.OnOp("WriteStream", key, int(cr.n), time.Since(start), err)
+	return err
+}
+
+// Counts the bytes read through it.
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// Reports a read with the number of bytes read once closed.
+type observedReader struct {
+	io.ReadCloser
+	s     *observed
+	op    string
+	key   string
+	start time.Time
+	size  int
+	err   error // First read error other than io.EOF.
+}
+
+func (r *observedReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.size += n
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
+func (r *observedReader) Close() error {
+	err := r.ReadCloser.Close()
+	if r.err != nil {
+		err = r.err
+	}
+	r.s.o.OnOp(r.op, r.key, r.size, time.Since(r.start), err)
+	return err
+}
+
+// Reports a write with the number of bytes written once closed.
+type observedWriter struct {
+	io.WriteCloser
+	s     *observed
+	key   string
+	start time.Time
+	size  int
+	err   error // First write error.
+}
+
+func (w *observedWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	w.size += n
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
+
+func (w *observedWriter) Close() error {
+	err := w.WriteCloser.Close()
+	if w.err != nil {
+		err = w.err
+	}
+	w.s.o.OnOp("Writer", w.key, w.size, time.Since(w.start), err)
+	return err
+}
@@ -0,0 +1,74 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/blob-go"
+)
+
+type observation struct {
+	op    string
+	key   string
+	bytes int
+	err   error
+}
+
+func TestObserved(t *testing.T) {
+	ctx := context.Background()
+	mem := blob.NewMemStorage()
+	if s := blob.NewObserved(mem, nil); s != blob.Storage(mem) {
+		t.Fatalf("Expected a nil observer to return the storage unwrapped")
+	}
+
+	var mu sync.Mutex
+	var got []observation
+	s := blob.NewObserved(mem, blob.ObserverFunc(func(op, key string, bytes int, dur time.Duration, err error) {
+		if dur < 0 {
+			t.Errorf("Negative duration %v for %s", dur, op)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, observation{op, key, bytes, err})
+	}))
+
+	if err := s.Write(ctx, "a.txt", []byte("hello")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := s.Read(ctx, "a.txt"); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	s.Read(ctx, "missing")
+	if err := s.WriteStream(ctx, "b.txt", strings.NewReader("stream")); err != nil {
+		t.Fatalf("WriteStream failed: %v", err)
+	}
+	rc, err := s.ReadStream(ctx, "b.txt")
+	if err != nil {
+		t.Fatalf("ReadStream failed: %v", err)
+	}
+	io.ReadAll(rc)
+	rc.Close()
+
+	want := []observation{
+		{"Write", "a.txt", 5, nil},
+		{"Read", "a.txt", 5, nil},
+		{"Read", "missing", 0, blob.ErrNotFound},
+		{"WriteStream", "b.txt", 6, nil},
+		{"ReadStream", "b.txt", 6, nil},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d observations, got %+v", len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.op != w.op || g.key != w.key || g.bytes != w.bytes || (w.err == nil) != (g.err == nil) ||
+			(w.err != nil && !errors.Is(g.err, w.err)) {
+			t.Fatalf("Observation %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
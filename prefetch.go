@@ -0,0 +1,117 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Iterates over blobs in order while fetching the following ones in the
+// background, overlapping their I/O with the processing of the current blob.
+//
+//	r := blob.NewPrefetchReader(ctx, s, keys, 4)
+//	defer r.Close()
+//	for r.Next() {
+//		process(r.Key(), r.Data())
+//	}
+//	if err := r.Err(); err != nil {
+//		...
+//	}
+type PrefetchReader struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	results chan chan prefetchResult
+	queued  bool // Whether all keys were queued, set before results is closed.
+
+	key  string
+	data []byte
+	err  error
+}
+
+// Blob fetched in the background.
+type prefetchResult struct {
+	key  string
+	data []byte
+	err  error
+}
+
+// Returns a reader yielding the blobs at keys in order, while fetching up to
+// ahead of the following blobs concurrently. Close must be called to stop the
+// background fetches when not iterating until the end.
+func NewPrefetchReader(ctx context.Context, s Storage, keys []string, ahead int) *PrefetchReader {
+	ahead = max(ahead, 1)
+	ctx, cancel := context.WithCancel(ctx)
+	r := &PrefetchReader{
+		ctx:     ctx,
+		cancel:  cancel,
+		results: make(chan chan prefetchResult, ahead),
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer close(r.results)
+		for _, key := range keys {
+			// Buffered, so fetches complete even if nobody reads their result.
+			result := make(chan prefetchResult, 1)
+			select {
+			case r.results <- result:
+			case <-ctx.Done():
+				return
+			}
+			r.wg.Add(1)
+			go func() {
+				defer r.wg.Done()
+				data, err := s.Read(ctx, key)
+				result <- prefetchResult{key: key, data: data, err: err}
+			}()
+		}
+		r.queued = true
+	}()
+	return r
+}
+
+// Advances to the next blob, reporting false when all blobs were read or an
+// error occurred, see Err.
+func (r *PrefetchReader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+	result, ok := <-r.results
+	if !ok {
+		if !r.queued {
+			r.err = r.ctx.Err()
+		}
+		return false
+	}
+	res := <-result
+	if res.err != nil {
+		r.err = fmt.Errorf("reading %s: %w", res.key, res.err)
+		return false
+	}
+	r.key, r.data = res.key, res.data
+	return true
+}
+
+// Returns the key of the current blob.
+func (r *PrefetchReader) Key() string {
+	return r.key
+}
+
+// Returns the data of the current blob.
+func (r *PrefetchReader) Data() []byte {
+	return r.data
+}
+
+// Returns the error that stopped the iteration, if any.
+func (r *PrefetchReader) Err() error {
+	return r.err
+}
+
+// Stops all background fetches and waits for them to return.
+func (r *PrefetchReader) Close() error {
+	r.cancel()
+	r.wg.Wait()
+	return nil
+}
@@ -0,0 +1,58 @@
+package blob_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestPrefetchReader(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_prefetch_reader"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	var keys []string
+	for i := range 10 {
+		key := fmt.Sprintf("segments/%03d", i)
+		if err := localFS.Write(ctx, key, []byte(key)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		keys = append(keys, key)
+	}
+
+	r := blob.NewPrefetchReader(ctx, localFS, keys, 3)
+	defer r.Close()
+	i := 0
+	for r.Next() {
+		if r.Key() != keys[i] || string(r.Data()) != keys[i] {
+			t.Fatalf("Unexpected blob %d: %s = %s", i, r.Key(), r.Data())
+		}
+		i++
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("Iteration failed: %v", err)
+	}
+	if i != len(keys) {
+		t.Fatalf("Expected %d blobs, got %d", len(keys), i)
+	}
+
+	// Closing early stops prefetching
+	r = blob.NewPrefetchReader(ctx, localFS, keys, 2)
+	if !r.Next() {
+		t.Fatalf("Expected a first blob: %v", r.Err())
+	}
+	r.Close()
+
+	// Missing blobs stop the iteration with an error
+	r = blob.NewPrefetchReader(ctx, localFS, []string{keys[0], "segments/missing"}, 2)
+	defer r.Close()
+	for r.Next() {
+	}
+	if r.Err() == nil {
+		t.Fatalf("Expected an error for a missing blob")
+	}
+}
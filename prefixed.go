@@ -0,0 +1,140 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+// Scopes all keys to a prefix of the wrapped storage.
+type prefixed struct {
+	Storage
+	prefix string // Prepended to every key, empty or ending with a slash.
+}
+
+// Returns a view of s in which every key is relative to prefix, such as to
+// hand a subsystem the blobs under tenants/<id>/ only. Keys and folders are
+// validated with ValidateNoTraversal, so ".." segments cannot reach the blobs
+// of other prefixes, and RemoveFolder refuses the root of the view like that
+// of any storage. Listed keys are returned relative to prefix.
+//
+// Wrapping a view again concatenates the prefixes, so
+// NewPrefixed(NewPrefixed(s, "tenants"), "1") equals NewPrefixed(s, "tenants/1").
+func NewPrefixed(s Storage, prefix string) Storage {
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		prefix += "/"
+	}
+	if p, ok := s.(*prefixed); ok {
+		return &prefixed{Storage: p.Storage, prefix: p.prefix + prefix}
+	}
+	return &prefixed{Storage: s, prefix: prefix}
+}
+
+// Returns the key in the wrapped storage.
+func (p *prefixed) key(key string) (string, error) {
+	if err := ValidateNoTraversal(key); err != nil {
+		return "", err
+	}
+	return p.prefix + key, nil
+}
+
+func (p *prefixed) Read(ctx context.Context, key string) ([]byte, error) {
+	key, err := p.key(key)
+	if err != nil {
+		return nil, err
+	}
+	return p.Storage.Read(ctx, key)
+}
+
+func (p *prefixed) Write(ctx context.Context, key string, data []byte) error {
+	key, err := p.key(key)
+	if err != nil {
+		return err
+	}
+	return p.Storage.Write(ctx, key, data)
+}
+
+func (p *prefixed) WriteIfMissing(ctx context.Context, key string, data []byte) error {
+	key, err := p.key(key)
+	if err != nil {
+		return err
+	}
+	return p.Storage.WriteIfMissing(ctx, key, data)
+}
+
+// Keeps WriteNext atomic on storages that report whether they wrote.
+func (p *prefixed) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	key, err := p.key(key)
+	if err != nil {
+		return false, err
+	}
+	return writeIfMissing(ctx, p.Storage, key, data)
+}
+
+func (p *prefixed) Remove(ctx context.Context, key string) error {
+	key, err := p.key(key)
+	if err != nil {
+		return err
+	}
+	return p.Storage.Remove(ctx, key)
+}
+
+func (p *prefixed) RemoveFolder(ctx context.Context, folder string) error {
+	if err := checkFolder(folder); err != nil {
+		return err
+	}
+	folder, err := p.key(strings.Trim(folder, "/"))
+	if err != nil {
+		return err
+	}
+	return p.Storage.RemoveFolder(ctx, folder)
+}
+
+// Lists the keys under the prefix of the view, relative to it.
+func (p *prefixed) List(ctx context.Context, prefix string) ([]string, error) {
+	if dir := strings.TrimSuffix(prefix, "/"); dir != "" {
+		if err := ValidateNoTraversal(dir); err != nil {
+			return nil, err
+		}
+	}
+	keys, err := p.Storage.List(ctx, p.prefix+prefix)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, p.prefix)
+	}
+	return keys, nil
+}
+
+func (p *prefixed) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	key, err := p.key(key)
+	if err != nil {
+		return nil, err
+	}
+	return p.Storage.Reader(ctx, key)
+}
+
+func (p *prefixed) ReadStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	key, err := p.key(key)
+	if err != nil {
+		return nil, err
+	}
+	return p.Storage.ReadStream(ctx, key)
+}
+
+func (p *prefixed) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	key, err := p.key(key)
+	if err != nil {
+		return nil, err
+	}
+	return p.Storage.Writer(ctx, key)
+}
+
+func (p *prefixed) WriteStream(ctx context.Context, key string, r io.Reader) error {
+	key, err := p.key(key)
+	if err != nil {
+		return err
+	}
+	return p.Storage.WriteStream(ctx, key, r)
+}
@@ -0,0 +1,53 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestPrefixed(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_prefixed"
+	defer os.RemoveAll(basePath)
+
+	for name, s := range map[string]blob.Storage{"fs": blob.NewFsStorage(basePath), "mem": blob.NewMemStorage()} {
+		tenants := blob.NewPrefixed(s, "tenants")
+		one, two := blob.NewPrefixed(tenants, "1"), blob.NewPrefixed(s, "tenants/2/")
+		if err := one.Write(ctx, "docs/a.txt", []byte("one")); err != nil {
+			t.Fatalf("%s: Write failed: %v", name, err)
+		}
+		if err := two.Write(ctx, "docs/a.txt", []byte("two")); err != nil {
+			t.Fatalf("%s: Write failed: %v", name, err)
+		}
+		if data, err := s.Read(ctx, "tenants/1/docs/a.txt"); err != nil || string(data) != "one" {
+			t.Fatalf("%s: Expected the nested prefixes to be concatenated, got %q, %v", name, data, err)
+		}
+		keys, err := one.List(ctx, "")
+		if err != nil {
+			t.Fatalf("%s: List failed: %v", name, err)
+		}
+		if !reflect.DeepEqual(keys, []string{"docs/a.txt"}) {
+			t.Fatalf("%s: List = %v, want keys relative to the view", name, keys)
+		}
+
+		for _, key := range []string{"../2/docs/a.txt", "docs/../../2/docs/a.txt", ""} {
+			if _, err := one.Read(ctx, key); !errors.Is(err, blob.ErrInvalidKey) {
+				t.Fatalf("%s: Read(%q) should return ErrInvalidKey, got: %v", name, key, err)
+			}
+		}
+		if err := one.RemoveFolder(ctx, ""); !errors.Is(err, blob.ErrInvalidKey) {
+			t.Fatalf("%s: RemoveFolder of the view root should return ErrInvalidKey, got: %v", name, err)
+		}
+		if err := one.RemoveFolder(ctx, "docs"); err != nil {
+			t.Fatalf("%s: RemoveFolder failed: %v", name, err)
+		}
+		if data, err := two.Read(ctx, "docs/a.txt"); err != nil || string(data) != "two" {
+			t.Fatalf("%s: RemoveFolder reached another view, got %q, %v", name, data, err)
+		}
+	}
+}
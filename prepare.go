@@ -0,0 +1,44 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+)
+
+// Implemented by storages that can warm up their backend connection, such as
+// Gcs and Redis.
+type Preparer interface {
+	// Establishes the backend connection ahead of time, so the first real
+	// operation does not pay for connection and TLS setup. Calling it at
+	// startup and periodically while idle keeps the connection warm. This is
+	// a best-effort optimization: an error only means the warm-up failed, and
+	// operations still connect on demand.
+	Prepare(ctx context.Context) error
+}
+
+// Warms up s if it implements Preparer and does nothing otherwise.
+func Prepare(ctx context.Context, s Storage) error {
+	if p, ok := s.(Preparer); ok {
+		return p.Prepare(ctx)
+	}
+	return nil
+}
+
+// Opens a connection to Google Cloud Storage with a cheap bucket attributes
+// call, which requires the storage.buckets.get permission.
+func (g *Gcs) Prepare(ctx context.Context) error {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	if _, err := g.bucket.Attrs(ctx); err != nil {
+		return fmt.Errorf("getting bucket attributes: %w", err)
+	}
+	return nil
+}
+
+// Opens a connection to Redis with a ping.
+func (r *Redis) Prepare(ctx context.Context) error {
+	if err := r.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("pinging: %w", err)
+	}
+	return nil
+}
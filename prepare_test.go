@@ -0,0 +1,42 @@
+package blob_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestPrepare(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_prepare"
+	defer os.RemoveAll(basePath)
+
+	if err := blob.Prepare(ctx, blob.NewFsStorage(basePath)); err != nil {
+		t.Fatalf("Prepare of a storage without warm-up should do nothing, got: %v", err)
+	}
+
+	fake := newFakeGcs(t, "test-bucket")
+	gcs := fake.storage(t, "")
+	if err := blob.Prepare(ctx, gcs); err != nil {
+		t.Fatalf("Prepare of Gcs failed: %v", err)
+	}
+	if n := fake.count("GET bucket"); n != 1 {
+		t.Fatalf("Expected one bucket attributes call, got %d", n)
+	}
+	fake.intercept = func(*http.Request) int { return http.StatusForbidden }
+	if err := gcs.Prepare(ctx); err == nil {
+		t.Fatalf("Expected Prepare of Gcs to fail without permission")
+	}
+
+	r, srv := newTestRedis(t, "app")
+	if err := blob.Prepare(ctx, r); err != nil {
+		t.Fatalf("Prepare of Redis failed: %v", err)
+	}
+	srv.Close()
+	if err := r.Prepare(ctx); err == nil {
+		t.Fatalf("Expected Prepare of Redis to fail without a server")
+	}
+}
@@ -0,0 +1,182 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"path"
+	"sync"
+)
+
+// Returned when a write would exceed the storage quota of its folder.
+var ErrQuotaExceeded = errors.New("blob: quota exceeded")
+
+// Implemented by storages that can list blobs with their sizes, such as Fs and
+// Gcs.
+type InfoLister interface {
+	ListInfo(ctx context.Context, prefix string) ([]BlobInfo, error)
+}
+
+// Returns the total size of all blobs whose key starts with prefix. The
+// storage must implement InfoLister.
+func Usage(ctx context.Context, s Storage, prefix string) (int64, error) {
+	return usage(ctx, s, prefix, "")
+}
+
+// Returns the total size of all blobs whose key starts with prefix, excluding
+// the blob at the exclude key.
+func usage(ctx context.Context, s Storage, prefix string, exclude string) (int64, error) {
+	lister, ok := s.(InfoLister)
+	if !ok {
+		return 0, fmt.Errorf("listing sizes of %T: %w", s, errors.ErrUnsupported)
+	}
+	infos, err := lister.ListInfo(ctx, prefix)
+	if err != nil {
+		return 0, fmt.Errorf("listing blobs: %w", err)
+	}
+	var total int64
+	for _, info := range infos {
+		if info.Key != exclude {
+			total += info.Size
+		}
+	}
+	return total, nil
+}
+
+// Configures NewQuotaEnforced.
+type QuotaOption func(*quotaEnforced)
+
+// Serializes writes to the same folder, so concurrent writes cannot exceed the
+// quota together. Writes to different folders may still run concurrently.
+func WithStrictQuota() QuotaOption {
+	return func(q *quotaEnforced) {
+		q.strict = true
+	}
+}
+
+// Number of mutexes that strict quota enforcement spreads folders across.
+const quotaLockStripes = 64
+
+// Rejects writes that would exceed the quota of their folder.
+type quotaEnforced struct {
+	Storage
+	quotaBytes func(prefix string) int64
+	strict     bool
+	locks      [quotaLockStripes]sync.Mutex
+}
+
+// Wraps s so that writes are rejected with ErrQuotaExceeded if they would grow
+// the total size of the blobs in their folder, the parent prefix of the key,
+// beyond quotaBytes of that folder. Root-level keys belong to the folder "",
+// whose usage includes every blob in the storage. A negative quota means
+// unlimited. The wrapped storage must implement InfoLister, and the folder is
+// listed before every write.
+//
+// By default the check and the write are not atomic, so concurrent writes to
+// a folder can each pass the check and exceed the quota together. Use
+// WithStrictQuota to serialize writes per folder within this process. Writers
+// are only checked when they are opened, as their size is not known upfront.
+func NewQuotaEnforced(s Storage, quotaBytes func(prefix string) int64, opts ...QuotaOption) Storage {
+	q := &quotaEnforced{Storage: s, quotaBytes: quotaBytes}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+// Writes a blob if it fits within the quota of its folder.
+func (q *quotaEnforced) Write(ctx context.Context, key string, data []byte) error {
+	unlock := q.lock(key)
+	defer unlock()
+	if err := q.check(ctx, key, int64(len(data))); err != nil {
+		return err
+	}
+	return q.Storage.Write(ctx, key, data)
+}
+
+// Writes a blob if the key does not contain any data yet and it fits within
+// the quota of its folder.
+func (q *quotaEnforced) WriteIfMissing(ctx context.Context, key string, data []byte) error {
+	unlock := q.lock(key)
+	defer unlock()
+	if err := q.check(ctx, key, int64(len(data))); err != nil {
+		return err
+	}
+	return q.Storage.WriteIfMissing(ctx, key, data)
+}
+
+// Keeps WriteNext atomic on storages that report whether they wrote.
+func (q *quotaEnforced) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	unlock := q.lock(key)
+	defer unlock()
+	if err := q.check(ctx, key, int64(len(data))); err != nil {
+		return false, err
+	}
+	return writeIfMissing(ctx, q.Storage, key, data)
+}
+
+// Returns an io writerCloser if the folder is not already at its quota.
+func (q *quotaEnforced) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	unlock := q.lock(key)
+	defer unlock()
+	if err := q.check(ctx, key, 0); err != nil {
+		return nil, err
+	}
+	return q.Storage.Writer(ctx, key)
+}
+
+// Writes the data read from r if the folder is not already at its quota. Like
+// Writer, the size of the data is not known upfront and not checked.
+func (q *quotaEnforced) WriteStream(ctx context.Context, key string, r io.Reader) error {
+	unlock := q.lock(key)
+	defer unlock()
+	if err := q.check(ctx, key, 0); err != nil {
+		return err
+	}
+	return q.Storage.WriteStream(ctx, key, r)
+}
+
+// Checks whether writing size bytes to key stays within the folder's quota.
+func (q *quotaEnforced) check(ctx context.Context, key string, size int64) error {
+	folder := quotaFolder(key)
+	quota := q.quotaBytes(folder)
+	if quota < 0 {
+		return nil
+	}
+	prefix := folder
+	if prefix != "" {
+		prefix += "/"
+	}
+	// The blob being overwritten does not count towards the usage.
+	used, err := usage(ctx, q.Storage, prefix, key)
+	if err != nil {
+		return fmt.Errorf("getting folder usage: %w", err)
+	}
+	if used+size > quota {
+		return fmt.Errorf("%w: writing %d bytes to %s with %d of %d bytes used", ErrQuotaExceeded, size, folder, used, quota)
+	}
+	return nil
+}
+
+// Locks the folder of key in strict mode and returns the unlock function.
+func (q *quotaEnforced) lock(key string) func() {
+	if !q.strict {
+		return func() {}
+	}
+	h := fnv.New32a()
+	h.Write([]byte(quotaFolder(key)))
+	mu := &q.locks[h.Sum32()%quotaLockStripes]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// Returns the folder whose quota applies to key, which is "" for root-level
+// keys.
+func quotaFolder(key string) string {
+	if folder := path.Dir(key); folder != "." {
+		return folder
+	}
+	return ""
+}
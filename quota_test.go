@@ -0,0 +1,68 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestQuotaEnforced(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_quota_enforced"
+	defer os.RemoveAll(basePath)
+
+	quota := func(prefix string) int64 {
+		if prefix == "tenants/a" {
+			return 10
+		}
+		return -1
+	}
+	s := blob.NewQuotaEnforced(blob.NewFsStorage(basePath), quota, blob.WithStrictQuota())
+
+	if err := s.Write(ctx, "tenants/a/one", []byte("123456")); err != nil {
+		t.Fatalf("Write within quota failed: %v", err)
+	}
+	err := s.Write(ctx, "tenants/a/two", []byte("123456"))
+	if !errors.Is(err, blob.ErrQuotaExceeded) {
+		t.Fatalf("Write exceeding quota should return ErrQuotaExceeded, got: %v", err)
+	}
+	if err := s.Write(ctx, "tenants/a/one", []byte("12345678")); err != nil {
+		t.Fatalf("Overwrite within quota failed: %v", err)
+	}
+	if err := s.Write(ctx, "tenants/b/big", make([]byte, 100)); err != nil {
+		t.Fatalf("Write without quota failed: %v", err)
+	}
+
+	used, err := blob.Usage(ctx, blob.NewFsStorage(basePath), "tenants/")
+	if err != nil {
+		t.Fatalf("Usage failed: %v", err)
+	}
+	if used != 108 {
+		t.Fatalf("Expected usage of 108 bytes, got %d", used)
+	}
+}
+
+func TestQuotaEnforced_RootKeys(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_quota_enforced_root_keys"
+	defer os.RemoveAll(basePath)
+
+	quota := func(prefix string) int64 {
+		if prefix == "" {
+			return 10
+		}
+		return -1
+	}
+	s := blob.NewQuotaEnforced(blob.NewFsStorage(basePath), quota)
+
+	if err := s.Write(ctx, "one", []byte("123456")); err != nil {
+		t.Fatalf("Write within quota failed: %v", err)
+	}
+	err := s.Write(ctx, "two", []byte("123456"))
+	if !errors.Is(err, blob.ErrQuotaExceeded) {
+		t.Fatalf("Write exceeding root quota should return ErrQuotaExceeded, got: %v", err)
+	}
+}
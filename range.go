@@ -0,0 +1,50 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// Reads length bytes of the blob at the given key, starting at offset, such
+// as to serve an HTTP Range request. A negative length reads until the end of
+// the blob. The result is shorter if the blob ends before the range, and
+// empty if offset is at or beyond its end, so callers that need to reject
+// such ranges should check them against the size from Stat first.
+func (l *Fs) ReadRange(ctx context.Context, key string, offset, length int64) ([]byte, error) {
+	return readRange(ctx, l, key, offset, length)
+}
+
+// Reads length bytes of the blob at the given key, starting at offset, with a
+// ranged GCS read. A negative length reads until the end of the blob. The
+// result is shorter if the blob ends before the range, and empty if offset is
+// at or beyond its end, see Stat for validating ranges first.
+func (g *Gcs) ReadRange(ctx context.Context, key string, offset, length int64) ([]byte, error) {
+	return readRange(ctx, g, key, offset, length)
+}
+
+// Reads length bytes of the blob at the given key, starting at offset, with a
+// Range request. A negative length reads until the end of the blob. The
+// result is shorter if the blob ends before the range, and empty if offset is
+// at or beyond its end, see Stat for validating ranges first.
+func (h *HTTP) ReadRange(ctx context.Context, key string, offset, length int64) ([]byte, error) {
+	return readRange(ctx, h, key, offset, length)
+}
+
+// Implemented by storages that can copy part of a blob, such as Fs, Gcs and
+// HTTP.
+type rangeCopier interface {
+	ReadRangeTo(ctx context.Context, key string, offset, length int64, w io.Writer) (int64, error)
+}
+
+// Reads a range of a blob into memory with ReadRangeTo.
+func readRange(ctx context.Context, s rangeCopier, key string, offset, length int64) ([]byte, error) {
+	var buf bytes.Buffer
+	if length > 0 {
+		buf.Grow(int(min(length, 1<<20)))
+	}
+	if _, err := s.ReadRangeTo(ctx, key, offset, length, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
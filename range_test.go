@@ -0,0 +1,49 @@
+package blob_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestLocalFiles_ReadRange(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_read_range"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := localFS.Write(ctx, "video.bin", []byte("0123456789")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	tests := []struct {
+		offset, length int64
+		want           string
+	}{
+		{0, 4, "0123"},
+		{3, 4, "3456"},
+		{7, -1, "789"},
+		{8, 10, "89"},
+		{10, 5, ""},
+		{20, -1, ""},
+	}
+	for _, tt := range tests {
+		data, err := localFS.ReadRange(ctx, "video.bin", tt.offset, tt.length)
+		if err != nil {
+			t.Fatalf("ReadRange(%d, %d) failed: %v", tt.offset, tt.length, err)
+		}
+		if string(data) != tt.want {
+			t.Fatalf("ReadRange(%d, %d) = %q, want %q", tt.offset, tt.length, data, tt.want)
+		}
+	}
+
+	// Stat gives the size to validate a range against before reading it.
+	info, err := localFS.Stat(ctx, "video.bin")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Size != 10 {
+		t.Fatalf("Stat size = %d, want 10", info.Size)
+	}
+}
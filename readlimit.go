@@ -0,0 +1,41 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Returned by ReadLimit when a blob is larger than the limit.
+var ErrTooLarge = errors.New("blob: too large")
+
+// Reads the blob at key from s like Read, but fails with ErrTooLarge instead
+// of buffering more than maxBytes, such as for keys controlled by users. If s
+// implements Stater, the size is checked before downloading anything. The
+// read itself stops one byte past maxBytes either way, so a blob growing in
+// between or decompressed to more than its stored size is caught as well.
+func ReadLimit(ctx context.Context, s Storage, key string, maxBytes int64) ([]byte, error) {
+	if st, ok := s.(Stater); ok {
+		info, err := st.Stat(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if info.Size > maxBytes {
+			return nil, fmt.Errorf("%w: %s has %d bytes, limit is %d", ErrTooLarge, key, info.Size, maxBytes)
+		}
+	}
+	rc, err := s.ReadStream(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading: %w", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("%w: %s exceeds the limit of %d bytes", ErrTooLarge, key, maxBytes)
+	}
+	return data, nil
+}
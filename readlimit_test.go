@@ -0,0 +1,32 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestReadLimit(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_read_limit"
+	defer os.RemoveAll(basePath)
+
+	for name, s := range map[string]blob.Storage{"fs": blob.NewFsStorage(basePath), "mem": blob.NewMemStorage()} {
+		if err := s.Write(ctx, "upload", []byte("0123456789")); err != nil {
+			t.Fatalf("%s: Write failed: %v", name, err)
+		}
+		data, err := blob.ReadLimit(ctx, s, "upload", 10)
+		if err != nil || string(data) != "0123456789" {
+			t.Fatalf("%s: ReadLimit at the size = %q, %v", name, data, err)
+		}
+		if _, err := blob.ReadLimit(ctx, s, "upload", 9); !errors.Is(err, blob.ErrTooLarge) {
+			t.Fatalf("%s: ReadLimit below the size should return ErrTooLarge, got: %v", name, err)
+		}
+		if _, err := blob.ReadLimit(ctx, s, "missing", 10); !errors.Is(err, blob.ErrNotFound) {
+			t.Fatalf("%s: ReadLimit of a missing blob should return ErrNotFound, got: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Returned when a read does not deliver its first byte within
+// ReadOptions.FirstByteTimeout.
+var ErrFirstByteTimeout = errors.New("blob: first byte timeout")
+
+// Options for reading a blob. Zero fields are not applied.
+type ReadOptions struct {
+	// Fails the read with ErrFirstByteTimeout if opening the blob and
+	// receiving its first byte takes longer, even if ctx allows more time.
+	// Once the first byte arrived the body streams without this limit, so
+	// a slow backend can be failed over quickly without cutting off long
+	// transfers.
+	FirstByteTimeout time.Duration
+}
+
+// Reads the blob at key from s with the given options.
+func ReadWithOptions(ctx context.Context, s Storage, key string, opts ReadOptions) ([]byte, error) {
+	rc, err := ReadStreamWithOptions(ctx, s, key, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("reading: %w", err)
+	}
+	return data, nil
+}
+
+// Streams the blob at key from s with the given options. The caller must
+// close the returned reader.
+func ReadStreamWithOptions(ctx context.Context, s Storage, key string, opts ReadOptions) (io.ReadCloser, error) {
+	if opts.FirstByteTimeout <= 0 {
+		return s.ReadStream(ctx, key)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	timeout := fmt.Errorf("%w: no data from %s within %v", ErrFirstByteTimeout, key, opts.FirstByteTimeout)
+	timer := time.AfterFunc(opts.FirstByteTimeout, func() { cancel(timeout) })
+
+	rc, err := s.ReadStream(ctx, key)
+	if err != nil {
+		timer.Stop()
+		cancel(nil)
+		if errors.Is(context.Cause(ctx), ErrFirstByteTimeout) {
+			return nil, timeout
+		}
+		return nil, err
+	}
+	head := make([]byte, 512)
+	var n int
+	for n == 0 && err == nil {
+		n, err = rc.Read(head)
+	}
+	// The read context is cancelled once the timer fired, even if data
+	// arrived just in time.
+	if !timer.Stop() {
+		rc.Close()
+		cancel(nil)
+		return nil, timeout
+	}
+	return &firstByteReader{head: head[:n], headErr: err, rc: rc, cancel: cancel}, nil
+}
+
+// Returns the data read while waiting for the first byte before reading on.
+type firstByteReader struct {
+	head    []byte
+	headErr error // Error of the read that returned head.
+	rc      io.ReadCloser
+	cancel  context.CancelCauseFunc
+}
+
+func (r *firstByteReader) Read(p []byte) (int, error) {
+	if len(r.head) > 0 {
+		n := copy(p, r.head)
+		r.head = r.head[n:]
+		return n, nil
+	}
+	if r.headErr != nil {
+		return 0, r.headErr
+	}
+	return r.rc.Read(p)
+}
+
+func (r *firstByteReader) Close() error {
+	defer r.cancel(nil)
+	return r.rc.Close()
+}
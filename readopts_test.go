@@ -0,0 +1,72 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/blob-go"
+)
+
+// Delays opening streams and every read after the first.
+type slowStreams struct {
+	blob.Storage
+	openDelay time.Duration
+	readDelay time.Duration
+}
+
+func (s *slowStreams) ReadStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	select {
+	case <-time.After(s.openDelay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	rc, err := s.Storage.ReadStream(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &slowReader{ReadCloser: rc, delay: s.readDelay}, nil
+}
+
+type slowReader struct {
+	io.ReadCloser
+	delay time.Duration
+	reads int
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if r.reads++; r.reads > 1 {
+		time.Sleep(r.delay)
+	}
+	return r.ReadCloser.Read(p[:min(len(p), 4)])
+}
+
+func TestReadWithOptions_FirstByteTimeout(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_read_first_byte_timeout"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := localFS.Write(ctx, "segments/1.ts", []byte("0123456789")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	opts := blob.ReadOptions{FirstByteTimeout: 50 * time.Millisecond}
+
+	slowStart := &slowStreams{Storage: localFS, openDelay: time.Second}
+	if _, err := blob.ReadWithOptions(ctx, slowStart, "segments/1.ts", opts); !errors.Is(err, blob.ErrFirstByteTimeout) {
+		t.Fatalf("Read with slow first byte should return ErrFirstByteTimeout, got: %v", err)
+	}
+
+	// Once the first byte arrived, the transfer may take longer than the timeout.
+	slowBody := &slowStreams{Storage: localFS, readDelay: 30 * time.Millisecond}
+	data, err := blob.ReadWithOptions(ctx, slowBody, "segments/1.ts", opts)
+	if err != nil || string(data) != "0123456789" {
+		t.Fatalf("Read with slow body = %q, %v", data, err)
+	}
+	if _, err := blob.ReadWithOptions(ctx, localFS, "segments/missing.ts", opts); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Read of missing key should return ErrNotFound, got: %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Implements the Storage interface for Redis. Intended for small, ephemeral
+// blobs such as session payloads or temporary tokens.
+type Redis struct {
+	client *redis.Client
+	prefix string // Prefix prepended to all keys.
+}
+
+// Returns a new Redis blob storage instance.
+func NewRedisStorage(client *redis.Client, prefix string) *Redis {
+	return &Redis{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Reads a blob from Redis.
+func (r *Redis) Read(ctx context.Context, key string) (_ []byte, err error) {
+	defer annotate(&err, "redis", "Read", key)
+	key = path.Join(r.prefix, key)
+	data, err := r.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("getting key %s: %w", key, ErrNotFound)
+		}
+		return nil, fmt.Errorf("getting key: %w", err)
+	}
+	return data, nil
+}
+
+// Writes a blob to Redis without expiry.
+func (r *Redis) Write(ctx context.Context, key string, data []byte) (err error) {
+	defer annotate(&err, "redis", "Write", key)
+	return r.WriteWithTTL(ctx, key, data, 0)
+}
+
+// Writes a blob to Redis that expires after the given ttl. A zero ttl means
+// the blob never expires.
+func (r *Redis) WriteWithTTL(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	key = path.Join(r.prefix, key)
+	if err := r.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("setting key: %w", err)
+	}
+	return nil
+}
+
+// Writes a blob to Redis if the key does not contain any data yet
+func (r *Redis) WriteIfMissing(ctx context.Context, key string, data []byte) (err error) {
+	defer annotate(&err, "redis", "WriteIfMissing", key)
+	_, err = r.writeIfMissing(ctx, key, data)
+	return err
+}
+
+// Writes a blob to Redis if the key does not contain any data yet and reports
+// whether this call created it.
+func (r *Redis) WriteIfMissingOK(ctx context.Context, key string, data []byte) (_ bool, err error) {
+	defer annotate(&err, "redis", "WriteIfMissingOK", key)
+	return r.writeIfMissing(ctx, key, data)
+}
+
+// Writes a blob if the key does not contain any data yet and reports whether
+// it did.
+func (r *Redis) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	key = path.Join(r.prefix, key)
+	written, err := r.client.SetNX(ctx, key, data, 0).Result()
+	if err != nil {
+		return false, fmt.Errorf("setting key if missing: %w", err)
+	}
+	return written, nil
+}
+
+// Removes a blob from Redis.
+func (r *Redis) Remove(ctx context.Context, key string) (err error) {
+	defer annotate(&err, "redis", "Remove", key)
+	key = path.Join(r.prefix, key)
+	if err := r.client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("deleting key: %w", err)
+	}
+	return nil
+}
+
+// Removes all keys under the specified folder, scanning for them in batches.
+func (r *Redis) RemoveFolder(ctx context.Context, folder string) (err error) {
+	defer annotate(&err, "redis", "RemoveFolder", folder)
+	if err := checkFolder(folder); err != nil {
+		return err
+	}
+	folder = path.Join(r.prefix, folder)
+	match := escapeGlob(folder+"/") + "*"
+	it := r.client.Scan(ctx, 0, match, 100).Iterator()
+	batch := make([]string, 0, 100)
+	for it.Next(ctx) {
+		batch = append(batch, it.Val())
+		if len(batch) == cap(batch) {
+			if err := r.client.Del(ctx, batch...).Err(); err != nil {
+				return fmt.Errorf("deleting keys: %w", err)
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("scanning keys: %w", err)
+	}
+	if len(batch) > 0 {
+		if err := r.client.Del(ctx, batch...).Err(); err != nil {
+			return fmt.Errorf("deleting keys: %w", err)
+		}
+	}
+	return nil
+}
+
+// Lists the keys of all blobs starting with the given prefix, sorted by key.
+func (r *Redis) List(ctx context.Context, prefix string) (_ []string, err error) {
+	defer annotate(&err, "redis", "List", prefix)
+	if r.prefix != "" {
+		prefix = r.prefix + "/" + prefix
+	}
+	var keys []string
+	it := r.client.Scan(ctx, 0, escapeGlob(prefix)+"*", 100).Iterator()
+	for it.Next(ctx) {
+		key := it.Val()
+		if r.prefix != "" {
+			key = strings.TrimPrefix(key, r.prefix+"/")
+		}
+		keys = append(keys, key)
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("scanning keys: %w", err)
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// Returns an io readerCloser for the blob at the given key. The blob is read
+// fully into memory first.
+func (r *Redis) Reader(ctx context.Context, key string) (_ io.ReadCloser, err error) {
+	defer annotate(&err, "redis", "Reader", key)
+	data, err := r.Read(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Returns a reader of the blob at the given key. Redis has no streaming reads,
+// so the blob is read fully into memory first.
+func (r *Redis) ReadStream(ctx context.Context, key string) (_ io.ReadCloser, err error) {
+	defer annotate(&err, "redis", "ReadStream", key)
+	return r.Reader(ctx, key)
+}
+
+// Writes the data read from r to the blob at the given key. Redis has no
+// streaming writes, so the data is read fully into memory first.
+func (r *Redis) WriteStream(ctx context.Context, key string, rd io.Reader) (err error) {
+	defer annotate(&err, "redis", "WriteStream", key)
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return fmt.Errorf("reading data: %w", err)
+	}
+	return r.Write(ctx, key, data)
+}
+
+// Returns an io writerCloser for the blob at the given key. The data is
+// buffered in memory and only written to Redis on Close.
+func (r *Redis) Writer(ctx context.Context, key string) (_ io.WriteCloser, err error) {
+	defer annotate(&err, "redis", "Writer", key)
+	return &redisWriter{ctx: ctx, redis: r, key: key}, nil
+}
+
+// Buffers written data until it is closed.
+type redisWriter struct {
+	ctx   context.Context
+	redis *Redis
+	key   string
+	buf   bytes.Buffer
+}
+
+func (w *redisWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *redisWriter) Close() error {
+	return w.redis.Write(w.ctx, w.key, w.buf.Bytes())
+}
+
+// Escapes the special characters of a Redis glob pattern.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
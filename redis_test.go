@@ -0,0 +1,117 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/blob-go"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// Returns a Redis storage with the given prefix backed by an in-process server.
+func newTestRedis(t *testing.T, prefix string) (*blob.Redis, *miniredis.Miniredis) {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return blob.NewRedisStorage(client, prefix), srv
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	r, srv := newTestRedis(t, "app")
+
+	if err := r.Write(ctx, "users/1", []byte("one")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	data, err := r.Read(ctx, "users/1")
+	if err != nil || string(data) != "one" {
+		t.Fatalf("Read = %q, %v", data, err)
+	}
+	if !srv.Exists("app/users/1") {
+		t.Fatalf("Expected the key to be stored under the prefix, got %v", srv.Keys())
+	}
+	if _, err := r.Read(ctx, "users/missing"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Read of a missing key should return ErrNotFound, got: %v", err)
+	}
+
+	// List trims the storage prefix from the keys.
+	if err := r.Write(ctx, "users/2", []byte("two")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	keys, err := r.List(ctx, "users/")
+	if err != nil || !reflect.DeepEqual(keys, []string{"users/1", "users/2"}) {
+		t.Fatalf("List = %v, %v", keys, err)
+	}
+}
+
+func TestRedis_WriteWithTTL(t *testing.T) {
+	ctx := context.Background()
+	r, srv := newTestRedis(t, "")
+
+	if err := r.WriteWithTTL(ctx, "sessions/abc", []byte("token"), time.Minute); err != nil {
+		t.Fatalf("WriteWithTTL failed: %v", err)
+	}
+	if ttl := srv.TTL("sessions/abc"); ttl != time.Minute {
+		t.Fatalf("Expected a TTL of 1m, got %v", ttl)
+	}
+	srv.FastForward(2 * time.Minute)
+	if _, err := r.Read(ctx, "sessions/abc"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Read after expiry should return ErrNotFound, got: %v", err)
+	}
+}
+
+func TestRedis_RemoveFolder(t *testing.T) {
+	ctx := context.Background()
+	r, _ := newTestRedis(t, "app")
+
+	keys := []string{"users/1", "users/1/profile", "users/1/avatar", "users/10/profile"}
+	for _, key := range keys {
+		if err := r.Write(ctx, key, []byte("data")); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := r.RemoveFolder(ctx, "users/1"); err != nil {
+		t.Fatalf("RemoveFolder failed: %v", err)
+	}
+	left, err := r.List(ctx, "users/")
+	if err != nil || !reflect.DeepEqual(left, []string{"users/1", "users/10/profile"}) {
+		t.Fatalf("RemoveFolder should keep the same-named blob and sibling prefixes, left %v, %v", left, err)
+	}
+	if err := r.RemoveFolder(ctx, ""); err == nil {
+		t.Fatalf("RemoveFolder of the root should fail")
+	}
+}
+
+func TestRedis_GlobEscaping(t *testing.T) {
+	ctx := context.Background()
+	r, _ := newTestRedis(t, "")
+
+	// Each folder name is a glob pattern that would match the others.
+	folders := []string{"a*b", "a?b", "a[b]", "axb", `a\b`}
+	for _, folder := range folders {
+		if err := r.Write(ctx, folder+"/file", []byte("data")); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	for _, folder := range folders {
+		keys, err := r.List(ctx, folder+"/")
+		if err != nil || !reflect.DeepEqual(keys, []string{folder + "/file"}) {
+			t.Fatalf("List of %s = %v, %v", folder, keys, err)
+		}
+	}
+	if err := r.RemoveFolder(ctx, "a*b"); err != nil {
+		t.Fatalf("RemoveFolder failed: %v", err)
+	}
+	if err := r.RemoveFolder(ctx, "a[b]"); err != nil {
+		t.Fatalf("RemoveFolder failed: %v", err)
+	}
+	left, err := r.List(ctx, "")
+	if err != nil || !reflect.DeepEqual(left, []string{"a?b/file", `a\b/file`, "axb/file"}) {
+		t.Fatalf("RemoveFolder removed keys matching the unescaped pattern, left %v, %v", left, err)
+	}
+}
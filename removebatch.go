@@ -0,0 +1,45 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Removes the blobs at keys from s with at most concurrency removes in
+// flight, or DefaultRemoveFolderConcurrency if it is not positive. Missing
+// keys count as removed, like a repeated delete. Unlike WriteBatch, a failure
+// does not stop the other removes: every failure is returned, joined with
+// errors.Join, so callers can see all keys that remain.
+func RemoveBatch(ctx context.Context, s Storage, keys []string, concurrency int) error {
+	if concurrency <= 0 {
+		concurrency = DefaultRemoveFolderConcurrency
+	}
+	var mu sync.Mutex
+	var errs []error
+	var errG errgroup.Group
+	errG.SetLimit(concurrency)
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			break
+		}
+		errG.Go(func() error {
+			err := s.Remove(ctx, key)
+			if err == nil || errors.Is(err, ErrNotFound) {
+				return nil
+			}
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("removing %s: %w", key, err))
+			mu.Unlock()
+			return nil
+		})
+	}
+	errG.Wait()
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,45 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestRemoveBatch(t *testing.T) {
+	ctx := context.Background()
+	mem := blob.NewMemStorage()
+	for _, key := range []string{"a", "b", "c"} {
+		if err := mem.Write(ctx, key, []byte(key)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	// Missing keys count as removed.
+	if err := blob.RemoveBatch(ctx, mem, []string{"a", "b", "missing"}, 2); err != nil {
+		t.Fatalf("RemoveBatch failed: %v", err)
+	}
+	keys, err := mem.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "c" {
+		t.Fatalf("Expected only c to remain, got %v", keys)
+	}
+
+	// Every failure is reported, and the other keys are still removed.
+	policy := blob.NewKeyPolicy(mem, blob.ValidateAlphanumericKey)
+	err = blob.RemoveBatch(ctx, policy, []string{"Bad 1", "c", "Bad 2"}, 0)
+	if !errors.Is(err, blob.ErrInvalidKey) {
+		t.Fatalf("Expected ErrInvalidKey, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "Bad 1") || !strings.Contains(err.Error(), "Bad 2") {
+		t.Fatalf("Expected both failures to be reported, got: %v", err)
+	}
+	if exists, _ := mem.Exists(ctx, "c"); exists {
+		t.Fatalf("Valid key was not removed alongside the failures")
+	}
+}
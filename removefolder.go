@@ -0,0 +1,82 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Counts the deletes of a RemoveFolder in atomics, so tracking them barely
+// affects throughput, and reports progress after every delete.
+type removeTracker struct {
+	start    time.Time
+	progress func(RemoveFolderProgress) // Called after every delete, if set.
+	deleted  atomic.Int64
+	inFlight atomic.Int64
+}
+
+func newRemoveTracker(progress func(RemoveFolderProgress)) *removeTracker {
+	return &removeTracker{start: time.Now(), progress: progress}
+}
+
+// Runs a delete, counting it as in flight while it runs and as deleted once
+// it succeeded.
+func (t *removeTracker) delete(del func() error) error {
+	t.inFlight.Add(1)
+	err := del()
+	running := t.inFlight.Add(-1)
+	if err != nil {
+		return err
+	}
+	done := t.deleted.Add(1)
+	if t.progress != nil {
+		t.progress(RemoveFolderProgress{Deleted: done, InFlight: running, Elapsed: time.Since(t.start)})
+	}
+	return nil
+}
+
+// Removes all blobs under folder+"/" from s by listing them and removing them
+// one by one with at most concurrency removes in flight, or
+// DefaultRemoveFolderConcurrency if it is not positive, and returns the
+// number of removed blobs. progress, if not nil, is called after every
+// remove like with WithRemoveFolderProgress, so the throughput of any backend
+// can be measured at different concurrency levels. The first failed remove
+// cancels the rest, and blobs removed concurrently count as removed.
+func RemoveFolderConcurrently(ctx context.Context, s Storage, folder string, concurrency int, progress func(RemoveFolderProgress)) (int, error) {
+	if err := checkFolder(folder); err != nil {
+		return 0, err
+	}
+	keys, err := s.List(ctx, strings.Trim(folder, "/")+"/")
+	if err != nil {
+		return 0, fmt.Errorf("listing blobs: %w", err)
+	}
+	if concurrency <= 0 {
+		concurrency = DefaultRemoveFolderConcurrency
+	}
+	tracker := newRemoveTracker(progress)
+	errG, delCtx := errgroup.WithContext(ctx)
+	errG.SetLimit(concurrency)
+	for _, key := range keys {
+		if delCtx.Err() != nil {
+			break
+		}
+		errG.Go(func() error {
+			return tracker.delete(func() error {
+				err := s.Remove(delCtx, key)
+				if err != nil && !errors.Is(err, ErrNotFound) {
+					return fmt.Errorf("removing %s: %w", key, err)
+				}
+				return nil
+			})
+		})
+	}
+	if err := errG.Wait(); err != nil {
+		return int(tracker.deleted.Load()), err
+	}
+	return int(tracker.deleted.Load()), ctx.Err()
+}
@@ -0,0 +1,126 @@
+package blob_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+// Writes n blobs under folder.
+func fillFolder(tb testing.TB, s blob.Storage, folder string, n int) {
+	tb.Helper()
+	for i := range n {
+		if err := s.Write(context.Background(), fmt.Sprintf("%s/%05d", folder, i), []byte("x")); err != nil {
+			tb.Fatalf("Write failed: %v", err)
+		}
+	}
+}
+
+func TestRemoveFolderConcurrently(t *testing.T) {
+	ctx := context.Background()
+	mem := blob.NewMemStorage()
+	fillFolder(t, mem, "logs", 100)
+	if err := mem.Write(ctx, "logs", []byte("kept")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var calls, maxInFlight atomic.Int64
+	var last atomic.Value
+	n, err := blob.RemoveFolderConcurrently(ctx, mem, "logs", 4, func(p blob.RemoveFolderProgress) {
+		calls.Add(1)
+		for {
+			cur := maxInFlight.Load()
+			if p.InFlight <= cur || maxInFlight.CompareAndSwap(cur, p.InFlight) {
+				break
+			}
+		}
+		last.Store(p)
+	})
+	if err != nil {
+		t.Fatalf("RemoveFolderConcurrently failed: %v", err)
+	}
+	if n != 100 || calls.Load() != 100 {
+		t.Fatalf("Expected 100 removes and progress reports, got %d and %d", n, calls.Load())
+	}
+	if maxInFlight.Load() >= 4 {
+		t.Fatalf("Expected fewer than 4 other removes in flight, got %d", maxInFlight.Load())
+	}
+	if p := last.Load().(blob.RemoveFolderProgress); p.Deleted != 100 || p.DeletesPerSecond() <= 0 {
+		t.Fatalf("Unexpected final progress %+v", p)
+	}
+	if keys, _ := mem.List(ctx, "logs/"); len(keys) != 0 {
+		t.Fatalf("Expected an empty folder, got %v", keys)
+	}
+	if _, err := mem.Read(ctx, "logs"); err != nil {
+		t.Fatalf("Expected the blob named like the folder to be kept, got: %v", err)
+	}
+}
+
+func TestGcsBucket_RemoveFolderProgress(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGcs(t, "test-bucket")
+	for i := range 20 {
+		fake.put(fmt.Sprintf("someprefix/logs/%02d", i), []byte("x"), nil)
+	}
+	fake.put("someprefix/logs", []byte("kept"), nil)
+
+	var calls, maxInFlight atomic.Int64
+	progress := func(p blob.RemoveFolderProgress) {
+		calls.Add(1)
+		for {
+			cur := maxInFlight.Load()
+			if p.InFlight <= cur || maxInFlight.CompareAndSwap(cur, p.InFlight) {
+				break
+			}
+		}
+	}
+	gcs := fake.storage(t, "someprefix", blob.WithRemoveFolderConcurrency(4), blob.WithRemoveFolderProgress(progress))
+	if err := gcs.RemoveFolder(ctx, "logs"); err != nil {
+		t.Fatalf("RemoveFolder failed: %v", err)
+	}
+	if calls.Load() != 20 || fake.count("DELETE object") != 20 {
+		t.Fatalf("Expected 20 deletes and progress reports, got %d and %d", fake.count("DELETE object"), calls.Load())
+	}
+	// The finished delete is no longer counted as in flight.
+	if maxInFlight.Load() >= 4 {
+		t.Fatalf("RemoveFolder exceeded its concurrency limit with %d other deletes in flight", maxInFlight.Load())
+	}
+	if fake.object("someprefix/logs") == nil {
+		t.Fatalf("Expected the object named like the folder to be kept")
+	}
+}
+
+// Measures the throughput of concurrent removes against Mem, reported as
+// deletes/s by the progress callback, to compare concurrency levels.
+func BenchmarkRemoveFolder(b *testing.B) {
+	ctx := context.Background()
+	for _, concurrency := range []int{1, 4, 16, 64} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			mem := blob.NewMemStorage()
+			var rate float64
+			for range b.N {
+				b.StopTimer()
+				fillFolder(b, mem, "bench", 1000)
+				b.StartTimer()
+				var mu sync.Mutex
+				var final blob.RemoveFolderProgress
+				_, err := blob.RemoveFolderConcurrently(ctx, mem, "bench", concurrency, func(p blob.RemoveFolderProgress) {
+					mu.Lock()
+					defer mu.Unlock()
+					if p.Deleted > final.Deleted {
+						final = p
+					}
+				})
+				if err != nil {
+					b.Fatalf("RemoveFolderConcurrently failed: %v", err)
+				}
+				rate += final.DeletesPerSecond()
+			}
+			b.ReportMetric(rate/float64(b.N), "deletes/s")
+		})
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !(plan9 || windows)
+
+package blob
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Reports whether a rename failed because source and destination are on
+// different file systems.
+func isCrossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
@@ -0,0 +1,15 @@
+//go:build plan9
+
+package blob
+
+import (
+	"errors"
+	"os"
+)
+
+// Reports whether a rename failed because it cannot move the file there.
+// Plan 9 only renames within a directory and rejects other renames as
+// invalid.
+func isCrossDevice(err error) bool {
+	return errors.Is(err, os.ErrInvalid)
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package blob
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// Reports whether a rename failed because source and destination are on
+// different volumes.
+func isCrossDevice(err error) bool {
+	return errors.Is(err, windows.ERROR_NOT_SAME_DEVICE)
+}
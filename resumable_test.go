@@ -0,0 +1,69 @@
+package blob_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+	"google.golang.org/api/option"
+)
+
+func TestGcsBucket_StartResumableUpload(t *testing.T) {
+	ctx := context.Background()
+	var got struct {
+		path, name, uploadType, origin, contentType string
+		body                                        map[string]string
+	}
+	var conns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.path = r.URL.Path
+		got.name = r.URL.Query().Get("name")
+		got.uploadType = r.URL.Query().Get("uploadType")
+		got.origin = r.Header.Get("Origin")
+		got.contentType = r.Header.Get("X-Upload-Content-Type")
+		json.NewDecoder(r.Body).Decode(&got.body)
+		w.Header().Set("Location", "http://"+r.Host+"/session/1")
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	gcs, err := blob.NewGcsStorage(ctx, "test-bucket", "uploads",
+		blob.WithClientOptions(option.WithEndpoint(srv.URL+"/storage/v1/"), option.WithoutAuthentication()))
+	if err != nil {
+		t.Fatalf("NewGcsStorage failed: %v", err)
+	}
+	session, err := gcs.StartResumableUpload(ctx, "video.mp4", blob.ResumableUploadOptions{
+		ContentType: "video/mp4",
+		Origin:      "https://app.example.com",
+	})
+	if err != nil {
+		t.Fatalf("StartResumableUpload failed: %v", err)
+	}
+	if session != srv.URL+"/session/1" {
+		t.Fatalf("Expected the session URL of the server, got %s", session)
+	}
+	if got.path != "/upload/storage/v1/b/test-bucket/o" || got.uploadType != "resumable" || got.name != "uploads/video.mp4" {
+		t.Fatalf("Unexpected upload request %s with name %q and upload type %q", got.path, got.name, got.uploadType)
+	}
+	if got.origin != "https://app.example.com" || got.contentType != "video/mp4" || got.body["contentType"] != "video/mp4" {
+		t.Fatalf("Expected the origin and content type to be passed on, got %+v", got)
+	}
+
+	// Later sessions reuse the HTTP client and its connections.
+	if _, err := gcs.StartResumableUpload(ctx, "audio.mp3", blob.ResumableUploadOptions{}); err != nil {
+		t.Fatalf("StartResumableUpload failed: %v", err)
+	}
+	if n := conns.Load(); n != 1 {
+		t.Fatalf("Expected both sessions to share one connection, got %d", n)
+	}
+}
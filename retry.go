@@ -0,0 +1,128 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"cloud.google.com/go/storage"
+)
+
+// Implemented by storages that know which of their errors are transient, so
+// retrying the operation may succeed.
+type RetryClassifier interface {
+	Retryable(err error) bool
+}
+
+// Reports whether retrying the operation of s that failed with err may
+// succeed, using the classification of s if it implements RetryClassifier.
+// Other storages only have their network errors retried. Callers can
+// override the classification by wrapping s in a type with its own
+// Retryable method.
+func Retryable(s Storage, err error) bool {
+	if c, ok := s.(RetryClassifier); ok {
+		return c.Retryable(err)
+	}
+	return !permanent(err) && isNetworkError(err)
+}
+
+// Reports whether GCS errors are transient: 408, 429 and 5xx responses as
+// well as connection resets and similar network errors.
+func (g *Gcs) Retryable(err error) bool {
+	return !permanent(err) && inChain(err, storage.ShouldRetry)
+}
+
+// Reports whether local file system errors are transient, such as
+// interrupted system calls.
+func (l *Fs) Retryable(err error) bool {
+	if permanent(err) {
+		return false
+	}
+	for _, errno := range transientErrnos {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether Redis errors are transient: network errors and servers
+// that are loading, failing over or asking to try again.
+func (r *Redis) Retryable(err error) bool {
+	if permanent(err) {
+		return false
+	}
+	if isNetworkError(err) {
+		return true
+	}
+	for _, prefix := range []string{"LOADING ", "TRYAGAIN ", "CLUSTERDOWN ", "MASTERDOWN "} {
+		if inChain(err, func(err error) bool { return strings.HasPrefix(err.Error(), prefix) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether HTTP errors are transient: 408, 429 and 5xx responses as
+// well as network errors.
+func (h *HTTP) Retryable(err error) bool {
+	if permanent(err) {
+		return false
+	}
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		code := statusErr.code
+		return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500
+	}
+	return isNetworkError(err)
+}
+
+// Reports whether err is never worth retrying, whatever the backend.
+func permanent(err error) bool {
+	if err == nil {
+		return true
+	}
+	for _, target := range []error{
+		context.Canceled, context.DeadlineExceeded, errors.ErrUnsupported,
+		ErrNotFound, ErrPreconditionFailed, ErrCaseCollision, ErrKeyTooLong,
+		ErrRetained, ErrQuotaExceeded, ErrTooLarge, ErrChecksumMismatch, ErrReadOnly, ErrClosed,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return isNotFound(err)
+}
+
+// Reports whether err is a network error, such as a timeout or a connection
+// that was reset or closed unexpectedly.
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed)
+}
+
+// Reports whether fn holds for err or any error it wraps.
+func inChain(err error, fn func(error) bool) bool {
+	for err != nil {
+		if fn(err) {
+			return true
+		}
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			err = u.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, e := range u.Unwrap() {
+				if inChain(e, fn) {
+					return true
+				}
+			}
+			return false
+		default:
+			return false
+		}
+	}
+	return false
+}
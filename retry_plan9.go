@@ -0,0 +1,8 @@
+//go:build plan9
+
+package blob
+
+import "syscall"
+
+// File system errors worth retrying.
+var transientErrnos = []error{syscall.EINTR, syscall.EBUSY}
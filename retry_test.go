@@ -0,0 +1,104 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/blob-go"
+	"google.golang.org/api/googleapi"
+)
+
+func TestRetryable(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_retryable"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	_, notFound := localFS.Read(ctx, "missing")
+	tests := []struct {
+		name string
+		s    blob.Storage
+		err  error
+		want bool
+	}{
+		{"fs not found", localFS, notFound, false},
+		{"fs interrupted", localFS, fmt.Errorf("reading: %w", syscall.EINTR), true},
+		{"gcs unavailable", &blob.Gcs{}, fmt.Errorf("reading: %w", &googleapi.Error{Code: 503}), true},
+		{"gcs throttled", &blob.Gcs{}, &googleapi.Error{Code: 429}, true},
+		{"gcs forbidden", &blob.Gcs{}, &googleapi.Error{Code: 403}, false},
+		{"gcs precondition", &blob.Gcs{}, fmt.Errorf("%w: %w", blob.ErrPreconditionFailed, &googleapi.Error{Code: 412}), false},
+		{"canceled", &blob.Gcs{}, context.Canceled, false},
+	}
+	for _, tt := range tests {
+		if got := blob.Retryable(tt.s, tt.err); got != tt.want {
+			t.Errorf("%s: Retryable(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryable_HTTP(t *testing.T) {
+	ctx := context.Background()
+	code := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+	}))
+	defer server.Close()
+
+	s := blob.NewHTTPStorage(server.URL, nil)
+	_, err := s.Read(ctx, "asset.js")
+	if !blob.Retryable(s, err) {
+		t.Fatalf("503 should be retryable: %v", err)
+	}
+	code = http.StatusForbidden
+	_, err = s.Read(ctx, "asset.js")
+	if err == nil || blob.Retryable(s, err) {
+		t.Fatalf("403 should not be retryable: %v", err)
+	}
+	if blob.Retryable(s, errors.New("unknown")) {
+		t.Fatalf("Unknown errors should not be retryable")
+	}
+}
+
+func TestGcsBucket_WithRetry(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGcs(t, "test-bucket")
+	gcs := fake.storage(t, "", blob.WithRetry(3, time.Millisecond))
+	requests, failures := 0, 2
+	fake.intercept = func(*http.Request) int {
+		if requests++; requests <= failures {
+			return http.StatusServiceUnavailable
+		}
+		return 0
+	}
+
+	// Writes without preconditions are retried as well.
+	if err := gcs.Write(ctx, "doc", []byte("data")); err != nil {
+		t.Fatalf("Write with two transient failures failed: %v", err)
+	}
+	if requests != 3 || fake.object("doc") == nil {
+		t.Fatalf("Expected the write to succeed on the third attempt, got %d requests", requests)
+	}
+
+	requests, failures = 0, 0
+	if _, err := gcs.Read(ctx, "missing"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Read of a missing key should return ErrNotFound, got: %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("Expected a 404 not to be retried, got %d requests", requests)
+	}
+
+	requests, failures = 0, 10
+	if _, err := gcs.Read(ctx, "doc"); err == nil {
+		t.Fatalf("Expected Read to fail after all attempts")
+	}
+	if requests != 3 {
+		t.Fatalf("Expected 3 attempts, got %d requests", requests)
+	}
+}
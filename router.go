@@ -0,0 +1,227 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Routes every key to one of several backends.
+type router struct {
+	route    func(key string) int
+	backends []Storage
+}
+
+// Returns a Storage that stores every key in exactly one of backends, the one
+// at the index route returns for it, such as to spread load and data across
+// several buckets. HashRoute returns a consistent hashing route.
+//
+// The keys under a folder or prefix can be spread across all backends, so
+// RemoveFolder, List and ListInfo query every backend concurrently, the
+// listings merging the sorted keys of the backends into one sorted result. A
+// key listed by several backends, such as during a migration after the route
+// changed, is listed once, with the info of the backend it routes to. A route
+// outside of backends fails the call.
+func NewRouter(route func(key string) int, backends []Storage) Storage {
+	return &router{route: route, backends: backends}
+}
+
+// Returns a route across n backends using jump consistent hashing, so adding
+// an nth backend only moves about 1/n of the keys.
+func HashRoute(n int) func(key string) int {
+	return func(key string) int {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		return jumpHash(h.Sum64(), n)
+	}
+}
+
+// Maps a key hash to one of n buckets, see Lamping and Veach, "A Fast,
+// Minimal Memory, Consistent Hash Algorithm".
+func jumpHash(key uint64, n int) int {
+	var b, j int64 = -1, 0
+	for j < int64(n) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// Returns the backend of key.
+func (r *router) backend(key string) (Storage, error) {
+	i := r.route(key)
+	if i < 0 || i >= len(r.backends) {
+		return nil, fmt.Errorf("routing key %s to backend %d of %d", key, i, len(r.backends))
+	}
+	return r.backends[i], nil
+}
+
+func (r *router) Read(ctx context.Context, key string) ([]byte, error) {
+	s, err := r.backend(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.Read(ctx, key)
+}
+
+func (r *router) Write(ctx context.Context, key string, data []byte) error {
+	s, err := r.backend(key)
+	if err != nil {
+		return err
+	}
+	return s.Write(ctx, key, data)
+}
+
+func (r *router) WriteIfMissing(ctx context.Context, key string, data []byte) error {
+	s, err := r.backend(key)
+	if err != nil {
+		return err
+	}
+	return s.WriteIfMissing(ctx, key, data)
+}
+
+// Keeps WriteNext atomic on backends that report whether they wrote.
+func (r *router) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	s, err := r.backend(key)
+	if err != nil {
+		return false, err
+	}
+	return writeIfMissing(ctx, s, key, data)
+}
+
+func (r *router) Remove(ctx context.Context, key string) error {
+	s, err := r.backend(key)
+	if err != nil {
+		return err
+	}
+	return s.Remove(ctx, key)
+}
+
+// Removes the folder from every backend.
+func (r *router) RemoveFolder(ctx context.Context, folder string) error {
+	errG, ctx := errgroup.WithContext(ctx)
+	for i, s := range r.backends {
+		errG.Go(func() error {
+			if err := s.RemoveFolder(ctx, folder); err != nil {
+				return fmt.Errorf("removing folder from backend %d: %w", i, err)
+			}
+			return nil
+		})
+	}
+	return errG.Wait()
+}
+
+// Lists the prefix on every backend and merges the keys, sorted by key.
+func (r *router) List(ctx context.Context, prefix string) ([]string, error) {
+	listings, err := listBackends(ctx, r.backends, func(ctx context.Context, s Storage) ([]string, error) {
+		return s.List(ctx, prefix)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mergeListings(r, listings, func(key string) string { return key }), nil
+}
+
+// Lists the prefix on every backend, which must all implement InfoLister,
+// and merges the infos, sorted by key.
+func (r *router) ListInfo(ctx context.Context, prefix string) ([]BlobInfo, error) {
+	for i, s := range r.backends {
+		if _, ok := s.(InfoLister); !ok {
+			return nil, fmt.Errorf("listing sizes of backend %d: %w", i, errors.ErrUnsupported)
+		}
+	}
+	listings, err := listBackends(ctx, r.backends, func(ctx context.Context, s Storage) ([]BlobInfo, error) {
+		return s.(InfoLister).ListInfo(ctx, prefix)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mergeListings(r, listings, func(info BlobInfo) string { return info.Key }), nil
+}
+
+// Runs list on every backend concurrently and returns their listings.
+func listBackends[T any](ctx context.Context, backends []Storage, list func(context.Context, Storage) ([]T, error)) ([][]T, error) {
+	listings := make([][]T, len(backends))
+	errG, ctx := errgroup.WithContext(ctx)
+	for i, s := range backends {
+		errG.Go(func() error {
+			listing, err := list(ctx, s)
+			if err != nil {
+				return fmt.Errorf("listing backend %d: %w", i, err)
+			}
+			listings[i] = listing
+			return nil
+		})
+	}
+	if err := errG.Wait(); err != nil {
+		return nil, err
+	}
+	return listings, nil
+}
+
+// Merges the listings of the backends, each sorted by key, into one sorted
+// listing in a single pass. An entry whose key is listed by several backends
+// is taken from the backend the key routes to, or else from the first one.
+func mergeListings[T any](r *router, listings [][]T, key func(T) string) []T {
+	next := make([]int, len(listings))
+	var merged []T
+	for {
+		first := -1
+		for i, listing := range listings {
+			if next[i] < len(listing) && (first < 0 || key(listing[next[i]]) < key(listings[first][next[first]])) {
+				first = i
+			}
+		}
+		if first < 0 {
+			return merged
+		}
+		k := key(listings[first][next[first]])
+		entry, primary := listings[first][next[first]], r.route(k)
+		for i, listing := range listings {
+			if next[i] < len(listing) && key(listing[next[i]]) == k {
+				if i == primary {
+					entry = listing[next[i]]
+				}
+				next[i]++
+			}
+		}
+		merged = append(merged, entry)
+	}
+}
+
+func (r *router) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	s, err := r.backend(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.Reader(ctx, key)
+}
+
+func (r *router) ReadStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	s, err := r.backend(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.ReadStream(ctx, key)
+}
+
+func (r *router) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	s, err := r.backend(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.Writer(ctx, key)
+}
+
+func (r *router) WriteStream(ctx context.Context, key string, rd io.Reader) error {
+	s, err := r.backend(key)
+	if err != nil {
+		return err
+	}
+	return s.WriteStream(ctx, key, rd)
+}
@@ -0,0 +1,108 @@
+package blob_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestRouter(t *testing.T) {
+	ctx := context.Background()
+	backends := []blob.Storage{blob.NewMemStorage(), blob.NewMemStorage(), blob.NewMemStorage()}
+	s := blob.NewRouter(blob.HashRoute(len(backends)), backends)
+
+	var want []string
+	for i := range 30 {
+		key := fmt.Sprintf("users/%02d", i)
+		want = append(want, key)
+		if err := s.Write(ctx, key, []byte(key)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	for i, backend := range backends {
+		keys, err := backend.List(ctx, "users/")
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(keys) == 0 || len(keys) == len(want) {
+			t.Fatalf("Backend %d holds %d of %d keys, want a share", i, len(keys), len(want))
+		}
+	}
+	if data, err := s.Read(ctx, "users/07"); err != nil || string(data) != "users/07" {
+		t.Fatalf("Read = %q, %v", data, err)
+	}
+
+	keys, err := s.List(ctx, "users/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("List = %v, want %v", keys, want)
+	}
+
+	if err := s.RemoveFolder(ctx, "users"); err != nil {
+		t.Fatalf("RemoveFolder failed: %v", err)
+	}
+	if keys, _ := s.List(ctx, "users/"); len(keys) != 0 {
+		t.Fatalf("Expected no keys after RemoveFolder, got %v", keys)
+	}
+}
+
+func TestRouter_OutOfRange(t *testing.T) {
+	ctx := context.Background()
+	s := blob.NewRouter(func(string) int { return 1 }, []blob.Storage{blob.NewMemStorage()})
+	if err := s.Write(ctx, "key", []byte("data")); err == nil {
+		t.Fatalf("Expected an error for a route outside of the backends")
+	}
+}
+
+func TestHashRoute(t *testing.T) {
+	// Growing from 4 to 5 backends should only move about a fifth of the keys.
+	four, five := blob.HashRoute(4), blob.HashRoute(5)
+	moved := 0
+	for i := range 1000 {
+		key := fmt.Sprintf("key/%d", i)
+		if four(key) != five(key) {
+			moved++
+		}
+	}
+	if moved > 300 {
+		t.Fatalf("Moved %d of 1000 keys, want about 200", moved)
+	}
+}
+
+func TestRouter_MergedListing(t *testing.T) {
+	ctx := context.Background()
+	old, current := blob.NewMemStorage(), blob.NewMemStorage()
+	// During a migration keys route to the current backend, but some are
+	// still or only stored in the old one.
+	s := blob.NewRouter(func(string) int { return 1 }, []blob.Storage{old, current})
+	for key, data := range map[string]string{"docs/a": "old a", "docs/b": "old b"} {
+		if err := old.Write(ctx, key, []byte(data)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	for key, data := range map[string]string{"docs/a": "new", "docs/c": "new c"} {
+		if err := s.Write(ctx, key, []byte(data)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	keys, err := s.List(ctx, "docs/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"docs/a", "docs/b", "docs/c"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("List = %v, want %v", keys, want)
+	}
+	infos, err := s.(blob.InfoLister).ListInfo(ctx, "docs/")
+	if err != nil {
+		t.Fatalf("ListInfo failed: %v", err)
+	}
+	if len(infos) != 3 || infos[0].Key != "docs/a" || infos[0].Size != 3 || infos[1].Size != 5 {
+		t.Fatalf("Expected each key once with the info of its backend, got %+v", infos)
+	}
+}
@@ -0,0 +1,115 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Default number of digits of the sequence numbers assigned by WriteNext.
+const DefaultSequenceDigits = 6
+
+// Default number of keys WriteNext tries before giving up.
+const DefaultSequenceAttempts = 10
+
+// Configures WriteNext.
+type SequenceOption func(*sequenceOptions)
+
+type sequenceOptions struct {
+	digits   int
+	attempts int
+}
+
+// Sets the number of digits sequence numbers are zero-padded to, so that keys
+// sort in sequence order. Larger numbers keep all their digits.
+func WithSequenceDigits(n int) SequenceOption {
+	return func(o *sequenceOptions) {
+		o.digits = n
+	}
+}
+
+// Sets how many sequence numbers WriteNext tries when concurrent writers keep
+// taking them first.
+func WithSequenceAttempts(n int) SequenceOption {
+	return func(o *sequenceOptions) {
+		o.attempts = n
+	}
+}
+
+// Implemented by storages that report whether WriteIfMissing wrote the blob.
+type missingWriter interface {
+	writeIfMissing(ctx context.Context, key string, data []byte) (bool, error)
+}
+
+// Writes data to the next free sequential key under prefix, such as
+// events/000001, events/000002, ... and returns the assigned key, building an
+// ordered append-only log without a coordinator.
+//
+// The highest sequence number is found by listing all keys under prefix, so
+// the cost of every call grows with the size of the log. The write only
+// succeeds if the key is still missing. When a concurrent writer takes the
+// key first, the next number is tried, up to DefaultSequenceAttempts keys in
+// total, see WithSequenceAttempts.
+//
+// Storages that do not report whether WriteIfMissing wrote, such as
+// decorators, are read back to detect collisions. A concurrent writer of the
+// same data is then indistinguishable from a successful write.
+func WriteNext(ctx context.Context, s Storage, prefix string, data []byte, opts ...SequenceOption) (string, error) {
+	o := sequenceOptions{digits: DefaultSequenceDigits, attempts: DefaultSequenceAttempts}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	keys, err := s.List(ctx, prefix)
+	if err != nil {
+		return "", fmt.Errorf("listing sequence: %w", err)
+	}
+	var last int64
+	for _, key := range keys {
+		n, err := strconv.ParseInt(strings.TrimPrefix(key, prefix), 10, 64)
+		if err == nil && n > last {
+			last = n
+		}
+	}
+
+	for seq := last + 1; seq <= last+int64(max(o.attempts, 1)); seq++ {
+		key := fmt.Sprintf("%s%0*d", prefix, o.digits, seq)
+		written, err := writeIfMissing(ctx, s, key, data)
+		if err != nil {
+			return "", fmt.Errorf("writing %s: %w", key, err)
+		}
+		if written {
+			return key, nil
+		}
+	}
+	return "", fmt.Errorf("no free sequence number after %d attempts: %w", o.attempts, ErrPreconditionFailed)
+}
+
+// Writes data to key on s if the key does not contain any data yet and reports
+// whether this call created it, such as to run first-time initialization only
+// once. Fs, Gcs, Mem, Redis and the decorators of this package report it
+// atomically when the storage they wrap does, except NewChunked, whose
+// WriteIfMissing is not atomic. Other storages are read back, so a
+// concurrent writer of the same data is then indistinguishable from a
+// successful write.
+func WriteIfMissingOK(ctx context.Context, s Storage, key string, data []byte) (bool, error) {
+	return writeIfMissing(ctx, s, key, data)
+}
+
+// Writes a blob if the key does not contain any data yet and reports whether
+// it did, reading it back for storages that cannot tell.
+func writeIfMissing(ctx context.Context, s Storage, key string, data []byte) (bool, error) {
+	if w, ok := s.(missingWriter); ok {
+		return w.writeIfMissing(ctx, key, data)
+	}
+	if err := s.WriteIfMissing(ctx, key, data); err != nil {
+		return false, err
+	}
+	stored, err := s.Read(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("reading back: %w", err)
+	}
+	return bytes.Equal(stored, data), nil
+}
@@ -0,0 +1,49 @@
+package blob_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestWriteNext(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_write_next"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	key, err := blob.WriteNext(ctx, localFS, "events", []byte("first"))
+	if err != nil || key != "events/000001" {
+		t.Fatalf("WriteNext = %q, %v, want events/000001", key, err)
+	}
+
+	// Concurrent writers each get their own key
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	assigned := map[string]bool{}
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key, err := blob.WriteNext(ctx, localFS, "events", []byte("event"))
+			if err != nil {
+				t.Errorf("WriteNext failed: %v", err)
+				return
+			}
+			mu.Lock()
+			assigned[key] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	keys, err := localFS.List(ctx, "events/")
+	if err != nil || len(keys) != 6 || len(assigned) != 5 {
+		t.Fatalf("Expected 6 events and 5 distinct keys, got %v and %v, %v", keys, assigned, err)
+	}
+	if keys[5] != "events/000006" {
+		t.Fatalf("Unexpected last key: %s", keys[5])
+	}
+}
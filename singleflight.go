@@ -0,0 +1,69 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Coalesces concurrent reads of the same key into a single backend read.
+type singleflightStorage struct {
+	Storage
+	group singleflight.Group
+}
+
+// Wraps s so that concurrent reads of the same key share a single read from s,
+// protecting the backend from a thundering herd on cold keys. When combined
+// with a cache, place it between the cache and the backend so a cache miss
+// results in one backend read rather than one per caller.
+//
+// The shared read runs with the context of the first caller, so cancelling it
+// fails the read for all callers waiting on it.
+func NewSingleflight(s Storage) Storage {
+	return &singleflightStorage{Storage: s}
+}
+
+// Reads a blob, sharing the result with concurrent reads of the same key.
+func (s *singleflightStorage) Read(ctx context.Context, key string) ([]byte, error) {
+	v, err, _ := s.group.Do(key, func() (any, error) {
+		return s.Storage.Read(ctx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Every caller gets its own copy, as callers may modify the returned slice.
+	return bytes.Clone(v.([]byte)), nil
+}
+
+// Writes a blob. Reads started afterwards do not join a read that is still in
+// flight, so they observe the new data.
+func (s *singleflightStorage) Write(ctx context.Context, key string, data []byte) error {
+	defer s.group.Forget(key)
+	return s.Storage.Write(ctx, key, data)
+}
+
+// Writes a blob if the key does not contain any data yet
+func (s *singleflightStorage) WriteIfMissing(ctx context.Context, key string, data []byte) error {
+	defer s.group.Forget(key)
+	return s.Storage.WriteIfMissing(ctx, key, data)
+}
+
+// Keeps WriteNext atomic on storages that report whether they wrote.
+func (s *singleflightStorage) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	defer s.group.Forget(key)
+	return writeIfMissing(ctx, s.Storage, key, data)
+}
+
+// Writes a blob from r
+func (s *singleflightStorage) WriteStream(ctx context.Context, key string, r io.Reader) error {
+	defer s.group.Forget(key)
+	return s.Storage.WriteStream(ctx, key, r)
+}
+
+// Removes a blob if it exists
+func (s *singleflightStorage) Remove(ctx context.Context, key string) error {
+	defer s.group.Forget(key)
+	return s.Storage.Remove(ctx, key)
+}
@@ -0,0 +1,53 @@
+package blob_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/blob-go"
+)
+
+// Counts reads and slows them down so concurrent reads overlap.
+type slowReads struct {
+	blob.Storage
+	reads atomic.Int32
+}
+
+func (s *slowReads) Read(ctx context.Context, key string) ([]byte, error) {
+	s.reads.Add(1)
+	time.Sleep(50 * time.Millisecond)
+	return s.Storage.Read(ctx, key)
+}
+
+func TestSingleflight(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_singleflight"
+	defer os.RemoveAll(basePath)
+
+	backend := &slowReads{Storage: blob.NewFsStorage(basePath)}
+	key := "hot/key.txt"
+	if err := backend.Write(ctx, key, []byte("hot")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	s := blob.NewSingleflight(backend)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := s.Read(ctx, key)
+			if err != nil || string(data) != "hot" {
+				t.Errorf("Read failed: %v, %s", err, data)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := backend.reads.Load(); n != 1 {
+		t.Fatalf("Expected 1 backend read, got %d", n)
+	}
+}
@@ -0,0 +1,137 @@
+package blob
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+
+	"cloud.google.com/go/storage"
+)
+
+// Detects the content type of data, reporting whether it recognized the data.
+type ContentDetector func(data []byte) (string, bool)
+
+// Detects content types with registered detectors, falling back to
+// http.DetectContentType for data none of them recognize.
+type Sniffer struct {
+	mu        sync.RWMutex
+	detectors []ContentDetector
+}
+
+// Returns a new Sniffer using the given detectors in order.
+func NewSniffer(detectors ...ContentDetector) *Sniffer {
+	return &Sniffer{detectors: detectors}
+}
+
+// Sniffer used by DetectContentType. It recognizes Parquet files in addition
+// to the formats known to http.DetectContentType.
+var DefaultSniffer = NewSniffer(
+	MagicDetector([]byte("PAR1"), "application/vnd.apache.parquet"),
+)
+
+// Registers a detector, which is tried after all previously registered ones.
+func (s *Sniffer) Register(d ContentDetector) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.detectors = append(s.detectors, d)
+}
+
+// Returns the content type of data. Like http.DetectContentType, at most the
+// first 512 bytes need to be passed.
+func (s *Sniffer) Detect(data []byte) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, d := range s.detectors {
+		if contentType, ok := d(data); ok {
+			return contentType
+		}
+	}
+	return http.DetectContentType(data)
+}
+
+// Returns the content type of data using DefaultSniffer.
+func DetectContentType(data []byte) string {
+	return DefaultSniffer.Detect(data)
+}
+
+// Returns a detector that recognizes data starting with magic as contentType.
+func MagicDetector(magic []byte, contentType string) ContentDetector {
+	return func(data []byte) (string, bool) {
+		return contentType, bytes.HasPrefix(data, magic)
+	}
+}
+
+// Detects the content type of Gcs writes that do not set one with s, such as
+// Write, Writer and WriteStream, instead of storing them as
+// DefaultContentType. Streaming writes buffer the first 512 bytes to detect
+// the type before uploading them.
+func WithSniffer(s *Sniffer) GcsOption {
+	return gcsOptionFunc(func(g *Gcs) {
+		g.sniffer = s
+	})
+}
+
+// Returns the content type of a write of data without one.
+func (g *Gcs) detectContentType(data []byte) string {
+	if g.sniffer == nil {
+		return DefaultContentType
+	}
+	return g.sniffer.Detect(data[:min(len(data), sniffLen)])
+}
+
+// Number of leading bytes a Sniffer needs to detect a content type.
+const sniffLen = 512
+
+// Returns a writer to wc that sets its content type, detecting it from the
+// first bytes written with the Sniffer of WithSniffer.
+func (g *Gcs) contentTypeWriter(wc *storage.Writer) io.WriteCloser {
+	if g.sniffer == nil {
+		wc.ContentType = DefaultContentType
+		return wc
+	}
+	return &sniffingWriter{wc: wc, sniffer: g.sniffer}
+}
+
+// Buffers the first bytes written to a storage writer until the content type
+// can be detected, as it must be set before the upload starts.
+type sniffingWriter struct {
+	wc      *storage.Writer
+	sniffer *Sniffer
+	head    []byte
+	started bool // Whether the content type is set and head was written.
+}
+
+func (w *sniffingWriter) Write(p []byte) (int, error) {
+	if w.started {
+		return w.wc.Write(p)
+	}
+	n := min(len(p), sniffLen-len(w.head))
+	w.head = append(w.head, p[:n]...)
+	if len(w.head) < sniffLen {
+		return len(p), nil
+	}
+	if err := w.start(); err != nil {
+		return 0, err
+	}
+	m, err := w.wc.Write(p[n:])
+	return n + m, err
+}
+
+// Sets the detected content type and writes the buffered head.
+func (w *sniffingWriter) start() error {
+	w.started = true
+	w.wc.ContentType = w.sniffer.Detect(w.head)
+	_, err := w.wc.Write(w.head)
+	return err
+}
+
+func (w *sniffingWriter) Close() error {
+	if !w.started {
+		if err := w.start(); err != nil {
+			w.wc.Close()
+			return err
+		}
+	}
+	return w.wc.Close()
+}
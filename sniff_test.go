@@ -0,0 +1,78 @@
+package blob_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestSniffer(t *testing.T) {
+	sniffer := blob.NewSniffer()
+	sniffer.Register(blob.MagicDetector([]byte("MYFMT"), "application/x-myformat"))
+
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{[]byte("MYFMT\x00\x01"), "application/x-myformat"},
+		{[]byte("plain text"), "text/plain; charset=utf-8"},
+		{[]byte("\x89PNG\r\n\x1a\n"), "image/png"},
+	}
+	for _, tt := range tests {
+		if got := sniffer.Detect(tt.data); got != tt.want {
+			t.Fatalf("Detect(%q) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+
+	if got := blob.DetectContentType([]byte("PAR1\x15\x04")); got != "application/vnd.apache.parquet" {
+		t.Fatalf("Expected parquet content type, got %q", got)
+	}
+}
+
+func TestGcsBucket_WithSniffer(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGcs(t, "test-bucket")
+	sniffer := blob.NewSniffer(blob.MagicDetector([]byte("MYFMT"), "application/x-myformat"))
+	gcs := fake.storage(t, "", blob.WithSniffer(sniffer))
+
+	if err := gcs.Write(ctx, "small.bin", []byte("MYFMT\x00\x01")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	wc, err := gcs.Writer(ctx, "writer.bin")
+	if err != nil {
+		t.Fatalf("Writer failed: %v", err)
+	}
+	io.WriteString(wc, "MY")
+	io.WriteString(wc, "FMT and more")
+	if err := wc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	large := "<!DOCTYPE html>" + strings.Repeat("x", 2000)
+	if err := gcs.WriteStream(ctx, "page", strings.NewReader(large)); err != nil {
+		t.Fatalf("WriteStream failed: %v", err)
+	}
+	for name, want := range map[string]string{
+		"small.bin":  "application/x-myformat",
+		"writer.bin": "application/x-myformat",
+		"page":       "text/html; charset=utf-8",
+	} {
+		obj := fake.object(name)
+		if obj == nil || obj.contentType != want {
+			t.Fatalf("Expected %s to be stored as %s, got %+v", name, want, obj)
+		}
+	}
+	if obj := fake.object("page"); string(obj.data) != large {
+		t.Fatalf("Expected the streamed data to be stored in full, got %d bytes", len(obj.data))
+	}
+
+	// Without a sniffer, writes are stored as DefaultContentType.
+	if err := fake.storage(t, "").Write(ctx, "plain.bin", []byte("MYFMT")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if obj := fake.object("plain.bin"); obj == nil || obj.contentType != blob.DefaultContentType {
+		t.Fatalf("Expected DefaultContentType, got %+v", obj)
+	}
+}
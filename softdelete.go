@@ -0,0 +1,129 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Layout of the timestamp folder in the trash, which sorts chronologically.
+const trashTimeFormat = "20060102T150405.000000000Z"
+
+// Storage whose removes move blobs to a trash prefix, from which they can be
+// restored until they are purged.
+type SoftDelete struct {
+	Storage
+	trashPrefix string
+	retention   time.Duration
+}
+
+// Wraps s so that Remove and RemoveFolder move blobs to
+// trashPrefix/<timestamp>/<key> instead of deleting them, giving undo-delete
+// on backends without native soft-delete. Restore recovers a removed blob and
+// Purge permanently deletes trash older than retention. Trashed blobs take up
+// storage until purged, and List hides the trash prefix.
+//
+// Moving copies the blob through the client, so removing is as expensive as
+// reading and writing it.
+func NewSoftDelete(s Storage, trashPrefix string, retention time.Duration) *SoftDelete {
+	return &SoftDelete{Storage: s, trashPrefix: strings.TrimSuffix(trashPrefix, "/") + "/", retention: retention}
+}
+
+// Keeps WriteNext atomic on storages that report whether they wrote.
+func (d *SoftDelete) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	return writeIfMissing(ctx, d.Storage, key, data)
+}
+
+// Moves a blob to the trash.
+func (d *SoftDelete) Remove(ctx context.Context, key string) error {
+	return d.trash(ctx, key, time.Now())
+}
+
+// Moves all blobs of a folder to the trash, under the same timestamp.
+func (d *SoftDelete) RemoveFolder(ctx context.Context, folder string) error {
+	if err := checkFolder(folder); err != nil {
+		return err
+	}
+	keys, err := d.Storage.List(ctx, strings.TrimSuffix(folder, "/")+"/")
+	if err != nil {
+		return fmt.Errorf("listing folder: %w", err)
+	}
+	now := time.Now()
+	for _, key := range keys {
+		if err := d.trash(ctx, key, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lists the keys of all blobs starting with the given prefix, sorted by key,
+// leaving out the trash.
+func (d *SoftDelete) List(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := d.Storage.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var visible []string
+	for _, key := range keys {
+		if !strings.HasPrefix(key, d.trashPrefix) {
+			visible = append(visible, key)
+		}
+	}
+	return visible, nil
+}
+
+// Restores the most recently removed version of the blob at key, overwriting
+// the blob if it exists again. Returns ErrNotFound if the trash contains no
+// version of it. The whole trash is listed to find it.
+func (d *SoftDelete) Restore(ctx context.Context, key string) error {
+	entries, err := d.Storage.List(ctx, d.trashPrefix)
+	if err != nil {
+		return fmt.Errorf("listing trash: %w", err)
+	}
+	latest := ""
+	for _, entry := range entries {
+		stamp, rel, ok := strings.Cut(strings.TrimPrefix(entry, d.trashPrefix), "/")
+		if ok && rel == key && entry > latest {
+			latest = d.trashPrefix + stamp + "/" + rel
+		}
+	}
+	if latest == "" {
+		return fmt.Errorf("%w: %s in trash", ErrNotFound, key)
+	}
+	if err := MoveAcross(ctx, d.Storage, latest, d.Storage, key); err != nil {
+		return fmt.Errorf("restoring: %w", err)
+	}
+	return nil
+}
+
+// Permanently deletes all trash that was removed longer than the retention
+// ago.
+func (d *SoftDelete) Purge(ctx context.Context) error {
+	entries, err := d.Storage.List(ctx, d.trashPrefix)
+	if err != nil {
+		return fmt.Errorf("listing trash: %w", err)
+	}
+	cutoff := time.Now().Add(-d.retention)
+	for _, entry := range entries {
+		stamp, _, _ := strings.Cut(strings.TrimPrefix(entry, d.trashPrefix), "/")
+		removed, err := time.Parse(trashTimeFormat, stamp)
+		if err != nil || removed.After(cutoff) {
+			continue // Not trash or still retained
+		}
+		if err := d.Storage.Remove(ctx, entry); err != nil && !isNotFound(err) {
+			return fmt.Errorf("purging %s: %w", entry, err)
+		}
+	}
+	return nil
+}
+
+// Moves a blob to the trash folder of the given removal time.
+func (d *SoftDelete) trash(ctx context.Context, key string, removed time.Time) error {
+	trashKey := d.trashPrefix + removed.UTC().Format(trashTimeFormat) + "/" + key
+	if err := MoveAcross(ctx, d.Storage, key, d.Storage, trashKey); err != nil {
+		return fmt.Errorf("moving to trash: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_soft_delete"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	s := blob.NewSoftDelete(localFS, ".trash", time.Hour)
+	if err := s.Write(ctx, "docs/a.txt", []byte("a")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := s.Write(ctx, "docs/b.txt", []byte("b")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	if err := s.Remove(ctx, "docs/a.txt"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := s.RemoveFolder(ctx, "docs"); err != nil {
+		t.Fatalf("RemoveFolder failed: %v", err)
+	}
+	keys, err := s.List(ctx, "")
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("Expected no visible blobs, got %v, %v", keys, err)
+	}
+
+	if err := s.Restore(ctx, "docs/a.txt"); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	data, err := s.Read(ctx, "docs/a.txt")
+	if err != nil || string(data) != "a" {
+		t.Fatalf("Read of restored blob = %q, %v", data, err)
+	}
+	if err := s.Restore(ctx, "docs/missing.txt"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Restore of unknown key should return ErrNotFound, got: %v", err)
+	}
+
+	// Nothing is purged within the retention, everything after it
+	if err := s.Purge(ctx); err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if trash, _ := localFS.List(ctx, ".trash/"); len(trash) != 1 {
+		t.Fatalf("Expected 1 blob in trash, got %v", trash)
+	}
+	if err := blob.NewSoftDelete(localFS, ".trash", 0).Purge(ctx); err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if trash, _ := localFS.List(ctx, ".trash/"); len(trash) != 0 {
+		t.Fatalf("Expected empty trash after purge, got %v", trash)
+	}
+}
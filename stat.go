@@ -0,0 +1,152 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Implemented by storages that can describe a single blob, such as Fs, Gcs
+// and HTTP.
+type Stater interface {
+	// Returns the info of the blob at key, failing with ErrNotFound if it
+	// does not exist.
+	Stat(ctx context.Context, key string) (*BlobInfo, error)
+}
+
+// Number of concurrent Stat calls of StatBatch if no concurrency is given.
+const DefaultStatConcurrency = 16
+
+// Stats many keys of s with at most concurrency calls in flight, or
+// DefaultStatConcurrency if it is not positive. Missing keys are omitted from
+// the returned map. On the first other error, including cancellation of ctx,
+// the remaining keys are skipped and the infos gathered so far are returned
+// along with the error.
+func StatBatch(ctx context.Context, s Stater, keys []string, concurrency int) (map[string]*BlobInfo, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultStatConcurrency
+	}
+	var mu sync.Mutex
+	infos := make(map[string]*BlobInfo, len(keys))
+	errG, gctx := errgroup.WithContext(ctx)
+	errG.SetLimit(concurrency)
+	for _, key := range keys {
+		if gctx.Err() != nil {
+			break
+		}
+		errG.Go(func() error {
+			info, err := s.Stat(gctx, key)
+			if errors.Is(err, ErrNotFound) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("stating %s: %w", key, err)
+			}
+			mu.Lock()
+			infos[key] = info
+			mu.Unlock()
+			return nil
+		})
+	}
+	err := errG.Wait()
+	if err == nil {
+		err = ctx.Err()
+	}
+	return infos, err
+}
+
+// Returns the size, modification time, content type, encoding, cache control
+// and metadata of the blob at the given key. Blobs written without a content
+// type report DefaultContentType. Files without a recorded type, such as
+// those written before types were tracked, have it detected from the key's
+// extension or with DetectContentType on the first 512 bytes. The ETag is
+// derived from the modification time and size, like Version.
+func (l *Fs) Stat(ctx context.Context, key string) (*BlobInfo, error) {
+	ctx, cancel := withDefaultTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	path, err := l.filePath(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stating file: %w", wrapNotFound(err))
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%w: %s is a folder", ErrNotFound, key)
+	}
+	m, err := l.readMeta(key)
+	if err != nil {
+		return nil, err
+	}
+	contentType, err := l.contentType(key, path, m)
+	if err != nil {
+		return nil, err
+	}
+	return &BlobInfo{
+		Key:             key,
+		Size:            info.Size(),
+		ModTime:         info.ModTime(),
+		Metadata:        m.Metadata,
+		ContentType:     contentType,
+		ETag:            fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size()),
+		ContentEncoding: m.ContentEncoding,
+		CacheControl:    m.CacheControl,
+	}, nil
+}
+
+// Returns the size, update time, content type, ETag, encoding, cache control
+// and custom metadata of the object at the given key.
+func (g *Gcs) Stat(ctx context.Context, key string) (*BlobInfo, error) {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	name, err := g.objectName(key)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := g.bucket.Object(name).Attrs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting attributes: %w", wrapNotFound(err))
+	}
+	return &BlobInfo{
+		Key:             key,
+		Size:            attrs.Size,
+		ModTime:         attrs.Updated,
+		Metadata:        attrs.Metadata,
+		ContentType:     gcsContentType(attrs),
+		ETag:            attrs.Etag,
+		ContentEncoding: attrs.ContentEncoding,
+		CacheControl:    attrs.CacheControl,
+	}, nil
+}
+
+// Returns the info of the blob at the given key from the headers of a HEAD
+// request. Fields whose headers are missing are left empty, and the size is
+// -1 if the length is unknown.
+func (h *HTTP) Stat(ctx context.Context, key string) (*BlobInfo, error) {
+	resp, err := h.do(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	info := &BlobInfo{
+		Key:             key,
+		Size:            resp.ContentLength,
+		ContentType:     resp.Header.Get("Content-Type"),
+		ETag:            resp.Header.Get("ETag"),
+		ContentEncoding: resp.Header.Get("Content-Encoding"),
+		CacheControl:    resp.Header.Get("Cache-Control"),
+	}
+	if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.ModTime = modTime
+	}
+	return info, nil
+}
@@ -0,0 +1,150 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestLocalFiles_Stat(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_stat"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := localFS.Write(ctx, "pages/index.html", []byte("<!DOCTYPE html><html></html>")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := blob.WriteJSON(ctx, localFS, "pages/data.json", []int{1, 2}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+
+	info, err := localFS.Stat(ctx, "pages/index.html")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Key != "pages/index.html" || info.Size != 28 || info.ModTime.IsZero() || info.ETag == "" {
+		t.Fatalf("Unexpected Stat result: %+v", info)
+	}
+	if info.ContentType != blob.DefaultContentType {
+		t.Fatalf("Stat should report DefaultContentType for a blob written without a type, got %q", info.ContentType)
+	}
+	// A file written without Fs has its type detected from its content.
+	if err := os.WriteFile(basePath+"/pages/legacy", []byte("<!DOCTYPE html><html></html>"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if legacy, _ := localFS.Stat(ctx, "pages/legacy"); legacy == nil || legacy.ContentType != "text/html; charset=utf-8" {
+		t.Fatalf("Stat should detect the type of a file without a recorded type, got %+v", legacy)
+	}
+	if info, _ := localFS.Stat(ctx, "pages/data.json"); info == nil || info.ContentType != blob.JSONContentType {
+		t.Fatalf("Stat should report the stored content type, got %+v", info)
+	}
+
+	if err := localFS.Write(ctx, "pages/index.html", []byte("<html>changed</html>")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if changed, _ := localFS.Stat(ctx, "pages/index.html"); changed == nil || changed.ETag == info.ETag {
+		t.Fatalf("ETag did not change after a write: %+v", changed)
+	}
+	for _, key := range []string{"pages/missing.html", "pages"} {
+		if _, err := localFS.Stat(ctx, key); !errors.Is(err, blob.ErrNotFound) {
+			t.Fatalf("Stat of %s should return ErrNotFound, got: %v", key, err)
+		}
+	}
+}
+
+func TestLocalFiles_StatWriteOptions(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_stat_write_options"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	opts := blob.WriteOptions{
+		ContentType:  "image/svg+xml",
+		CacheControl: "public, max-age=3600",
+		Metadata:     map[string]string{"owner": "web"},
+	}
+	if err := localFS.WriteWithOptions(ctx, "logo.svg", []byte("<svg/>"), opts); err != nil {
+		t.Fatalf("WriteWithOptions failed: %v", err)
+	}
+	info, err := localFS.Stat(ctx, "logo.svg")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.ContentType != opts.ContentType || info.CacheControl != opts.CacheControl || info.Metadata["owner"] != "web" {
+		t.Fatalf("Stat should return the write options, got %+v", info)
+	}
+
+	// A plain write replaces the blob without keeping its options.
+	if err := localFS.Write(ctx, "logo.svg", []byte("<svg/>")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if info, _ := localFS.Stat(ctx, "logo.svg"); info == nil || info.CacheControl != "" || info.Metadata != nil {
+		t.Fatalf("Stat should not return options of the previous write, got %+v", info)
+	}
+}
+
+func TestHTTP_Stat(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_http_stat"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := localFS.Write(ctx, "assets/app.css", []byte("body{}")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	server := httptest.NewServer(http.FileServer(http.Dir(basePath)))
+	defer server.Close()
+
+	s := blob.NewHTTPStorage(server.URL, nil)
+	info, err := s.Stat(ctx, "assets/app.css")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Size != 6 || info.ContentType != "text/css; charset=utf-8" || info.ModTime.IsZero() {
+		t.Fatalf("Unexpected Stat result: %+v", info)
+	}
+	if _, err := s.Stat(ctx, "assets/missing.css"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Stat of missing key should return ErrNotFound, got: %v", err)
+	}
+}
+
+func TestStatBatch(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_stat_batch"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	var keys []string
+	for i := range 20 {
+		key := fmt.Sprintf("manifest/%02d.bin", i)
+		if err := localFS.Write(ctx, key, make([]byte, i)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		keys = append(keys, key)
+	}
+	keys = append(keys, "manifest/missing.bin")
+
+	infos, err := blob.StatBatch(ctx, localFS, keys, 4)
+	if err != nil {
+		t.Fatalf("StatBatch failed: %v", err)
+	}
+	if len(infos) != 20 {
+		t.Fatalf("Expected 20 infos without the missing key, got %d", len(infos))
+	}
+	if info := infos["manifest/07.bin"]; info == nil || info.Size != 7 {
+		t.Fatalf("Unexpected info for manifest/07.bin: %+v", info)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := blob.StatBatch(cancelled, localFS, keys, 4); !errors.Is(err, context.Canceled) {
+		t.Fatalf("StatBatch with cancelled context should return context.Canceled, got: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Configures both Fs and Gcs instances.
+type Option interface {
+	FsOption
+	GcsOption
+}
+
+// Applies a default timeout to operations whose context has no deadline.
+type defaultTimeout time.Duration
+
+func (d defaultTimeout) applyFs(l *Fs)   { l.timeout = time.Duration(d) }
+func (d defaultTimeout) applyGcs(g *Gcs) { g.timeout = time.Duration(d) }
+
+// Bounds every Fs and Gcs method that takes a context to d when the context
+// has no deadline, so that a hung connection cannot block a caller passing
+// context.Background forever. A deadline set by the caller is kept, even if
+// it is later than d. WriteStream, ReadTo and ReadRangeTo are bounded as a
+// whole, so pass a context with a later deadline for large blobs. Reader,
+// ReadStream and Writer are not bounded, as they return before the transfer
+// ends.
+//
+// Fs only notices the timeout where it checks its context: before the file
+// operations of a method, between the reads of WriteStream and between the
+// files of a listing or folder removal. A single file operation is not
+// interrupted.
+func WithDefaultTimeout(d time.Duration) Option {
+	return defaultTimeout(d)
+}
+
+// Returns ctx with a timeout of d if it has no deadline and d is positive.
+func withDefaultTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// Reads from r until ctx is done, then fails with the error of ctx.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
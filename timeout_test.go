@@ -0,0 +1,103 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/blob-go"
+)
+
+// Blocks every request until its context is done, like a hung connection.
+type hangingTransport struct{}
+
+func (hangingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+}
+
+func TestDefaultTimeout_Gcs(t *testing.T) {
+	ctx := context.Background()
+	gcs, err := blob.NewGcsStorage(ctx, "test-bucket", "",
+		blob.WithHTTPClient(&http.Client{Transport: hangingTransport{}}),
+		blob.WithDefaultTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewGcsStorage failed: %v", err)
+	}
+	start := time.Now()
+	if _, err := gcs.Read(ctx, "hung.txt"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Read should time out, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Read took %v despite the default timeout", elapsed)
+	}
+
+	ops := map[string]func() error{
+		"Exists":       func() error { _, err := gcs.Exists(ctx, "hung.txt"); return err },
+		"ExistsFresh":  func() error { _, err := gcs.ExistsFresh(ctx, "hung.txt", time.Hour); return err },
+		"Version":      func() error { _, err := gcs.Version(ctx, "hung.txt"); return err },
+		"Stat":         func() error { _, err := gcs.Stat(ctx, "hung.txt"); return err },
+		"ListInfo":     func() error { _, err := gcs.ListInfo(ctx, ""); return err },
+		"ReadTo":       func() error { _, err := gcs.ReadTo(ctx, "hung.txt", io.Discard); return err },
+		"ReadRangeTo":  func() error { _, err := gcs.ReadRangeTo(ctx, "hung.txt", 0, 1, io.Discard); return err },
+		"Generation":   func() error { _, err := gcs.Generation(ctx, "hung.txt"); return err },
+		"Rewrite":      func() error { return gcs.Rewrite(ctx, "hung.txt", blob.RewriteOptions{StorageClass: "NEARLINE"}) },
+		"WriteOptions": func() error { return gcs.WriteWithOptions(ctx, "hung.txt", nil, blob.WriteOptions{}) },
+		"Update": func() error {
+			return gcs.Update(ctx, "hung.txt", func(current []byte) ([]byte, error) { return current, nil })
+		},
+		"UpdateMetadata": func() error {
+			return gcs.UpdateMetadata(ctx, "hung.txt", map[string]string{"a": "1"}, blob.MetadataMerge)
+		},
+		"ReadAtLeastGeneration": func() error { _, err := gcs.ReadAtLeastGeneration(ctx, "hung.txt", 1); return err },
+	}
+	for name, op := range ops {
+		if err := op(); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s should time out, got: %v", name, err)
+		}
+	}
+}
+
+func TestDefaultTimeout_Fs(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_default_timeout_fs"
+	defer os.RemoveAll(basePath)
+
+	if err := blob.NewFsStorage(basePath).Write(ctx, "docs/a.txt", []byte("a")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	localFS := blob.NewFsStorage(basePath, blob.WithDefaultTimeout(time.Nanosecond))
+	if _, err := localFS.List(ctx, "docs/"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("List should time out, got: %v", err)
+	}
+	if err := localFS.Write(ctx, "docs/b.txt", []byte("b")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Write should time out, got: %v", err)
+	}
+	if _, err := localFS.Exists(ctx, "docs/a.txt"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Exists should time out, got: %v", err)
+	}
+	if err := localFS.Remove(ctx, "docs/a.txt"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Remove should time out, got: %v", err)
+	}
+	if _, err := localFS.Stat(ctx, "docs/a.txt"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stat should time out, got: %v", err)
+	}
+	if err := localFS.WriteStream(ctx, "docs/c.txt", strings.NewReader("c")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WriteStream should time out, got: %v", err)
+	}
+	if exists, _ := blob.NewFsStorage(basePath).Exists(ctx, "docs/c.txt"); exists {
+		t.Fatal("A timed out WriteStream left a blob")
+	}
+
+	// A deadline set by the caller is kept instead.
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	if _, err := localFS.List(ctx, "docs/"); err != nil {
+		t.Fatalf("List with a caller deadline failed: %v", err)
+	}
+}
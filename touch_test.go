@@ -0,0 +1,43 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestLocalFiles_Touch(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_local_files_touch"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	if err := localFS.Write(ctx, "sessions/1", []byte("data")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(basePath, "sessions", "1"), old, old); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+	if fresh, err := localFS.ExistsFresh(ctx, "sessions/1", time.Hour); err != nil || fresh {
+		t.Fatalf("ExistsFresh before Touch = %v, %v, want false", fresh, err)
+	}
+	if err := localFS.Touch(ctx, "sessions/1"); err != nil {
+		t.Fatalf("Touch failed: %v", err)
+	}
+	if fresh, err := localFS.ExistsFresh(ctx, "sessions/1", time.Hour); err != nil || !fresh {
+		t.Fatalf("ExistsFresh after Touch = %v, %v, want true", fresh, err)
+	}
+	if data, _ := localFS.Read(ctx, "sessions/1"); string(data) != "data" {
+		t.Fatalf("Expected Touch to keep the data, got %q", data)
+	}
+
+	if err := localFS.Touch(ctx, "sessions/missing"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Touch of a missing blob should return ErrNotFound, got: %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Configures NewTransform.
+type TransformOption func(*transformed)
+
+// Caches transformed results in the wrapped storage under cachePrefix followed
+// by the key, e.g. "thumbs/" caches the transformed "photos/cat.jpg" at
+// "thumbs/photos/cat.jpg", so variants are addressable directly. Writes and
+// removals through the transform, including RemoveFolder, invalidate the
+// cached variants. Cached
+// variants are never expired, so the cache grows with the number of distinct
+// keys read.
+func WithTransformCache(cachePrefix string) TransformOption {
+	return func(t *transformed) {
+		t.cachePrefix = cachePrefix
+	}
+}
+
+// Applies a transformation to read results.
+type transformed struct {
+	Storage
+	transform   func(ctx context.Context, key string, data []byte) ([]byte, error)
+	cachePrefix string
+}
+
+// Wraps s so that the data of every read is passed through transform, such
+// as an image resizer, while writes pass through unchanged. Streaming reads
+// are buffered, as transform needs the whole blob. Transformed results are
+// only cached with WithTransformCache.
+func NewTransform(s Storage, transform func(ctx context.Context, key string, data []byte) ([]byte, error), opts ...TransformOption) Storage {
+	t := &transformed{Storage: s, transform: transform}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Reads a blob and returns its transformed data, from the cache if enabled.
+func (t *transformed) Read(ctx context.Context, key string) ([]byte, error) {
+	if t.cachePrefix != "" {
+		data, err := t.Storage.Read(ctx, t.cachePrefix+key)
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("reading cached variant: %w", err)
+		}
+	}
+	data, err := t.Storage.Read(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	data, err = t.transform(ctx, key, data)
+	if err != nil {
+		return nil, fmt.Errorf("transforming %s: %w", key, err)
+	}
+	if t.cachePrefix != "" {
+		// A failed cache write only costs transforming again on the next read.
+		if err := t.Storage.Write(ctx, t.cachePrefix+key, data); err != nil {
+			slog.Warn("blob: caching transformed blob failed", "key", key, "error", err)
+		}
+	}
+	return data, nil
+}
+
+func (t *transformed) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, err := t.Read(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (t *transformed) ReadStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	return t.Reader(ctx, key)
+}
+
+func (t *transformed) Write(ctx context.Context, key string, data []byte) error {
+	if err := t.Storage.Write(ctx, key, data); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, key)
+}
+
+func (t *transformed) WriteIfMissing(ctx context.Context, key string, data []byte) error {
+	if err := t.Storage.WriteIfMissing(ctx, key, data); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, key)
+}
+
+// Keeps WriteNext atomic on storages that report whether they wrote.
+func (t *transformed) writeIfMissing(ctx context.Context, key string, data []byte) (bool, error) {
+	written, err := writeIfMissing(ctx, t.Storage, key, data)
+	if err != nil {
+		return written, err
+	}
+	return written, t.invalidate(ctx, key)
+}
+
+func (t *transformed) WriteStream(ctx context.Context, key string, r io.Reader) error {
+	if err := t.Storage.WriteStream(ctx, key, r); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, key)
+}
+
+// Returns a writer that invalidates the cached variant once closed.
+func (t *transformed) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	wc, err := t.Storage.Writer(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &invalidatingWriter{WriteCloser: wc, ctx: ctx, t: t, key: key}, nil
+}
+
+func (t *transformed) Remove(ctx context.Context, key string) error {
+	if err := t.Storage.Remove(ctx, key); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, key)
+}
+
+// Removes a folder along with the cached variants of its blobs.
+func (t *transformed) RemoveFolder(ctx context.Context, folder string) error {
+	if err := t.Storage.RemoveFolder(ctx, folder); err != nil {
+		return err
+	}
+	if t.cachePrefix == "" {
+		return nil
+	}
+	if err := t.Storage.RemoveFolder(ctx, t.cachePrefix+folder); err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("invalidating cached variants: %w", err)
+	}
+	return nil
+}
+
+// Removes the cached variant of key if caching is enabled.
+func (t *transformed) invalidate(ctx context.Context, key string) error {
+	if t.cachePrefix == "" {
+		return nil
+	}
+	if err := t.Storage.Remove(ctx, t.cachePrefix+key); err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("invalidating cached variant: %w", err)
+	}
+	return nil
+}
+
+type invalidatingWriter struct {
+	io.WriteCloser
+	ctx context.Context
+	t   *transformed
+	key string
+}
+
+func (w *invalidatingWriter) Close() error {
+	if err := w.WriteCloser.Close(); err != nil {
+		return err
+	}
+	return w.t.invalidate(w.ctx, w.key)
+}
@@ -0,0 +1,65 @@
+package blob_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestTransform(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_transform"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	var calls atomic.Int32
+	upper := func(ctx context.Context, key string, data []byte) ([]byte, error) {
+		calls.Add(1)
+		return bytes.ToUpper(data), nil
+	}
+	s := blob.NewTransform(localFS, upper, blob.WithTransformCache("variants/"))
+
+	if err := s.Write(ctx, "photos/cat.txt", []byte("cat")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if data, _ := localFS.Read(ctx, "photos/cat.txt"); string(data) != "cat" {
+		t.Fatalf("Write should pass through unchanged, stored %q", data)
+	}
+	for range 2 {
+		data, err := s.Read(ctx, "photos/cat.txt")
+		if err != nil || string(data) != "CAT" {
+			t.Fatalf("Read = %q, %v, want CAT", data, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("Expected 1 transform with caching, got %d", n)
+	}
+	if data, _ := localFS.Read(ctx, "variants/photos/cat.txt"); string(data) != "CAT" {
+		t.Fatalf("Cached variant = %q, want CAT", data)
+	}
+
+	// A write invalidates the cached variant.
+	if err := s.Write(ctx, "photos/cat.txt", []byte("cats")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if data, _ := s.Read(ctx, "photos/cat.txt"); string(data) != "CATS" {
+		t.Fatalf("Read after Write = %q, want CATS", data)
+	}
+
+	// Removing the folder removes the cached variants too, so its blobs are
+	// not served from the cache any longer.
+	if err := s.RemoveFolder(ctx, "photos"); err != nil {
+		t.Fatalf("RemoveFolder failed: %v", err)
+	}
+	if _, err := s.Read(ctx, "photos/cat.txt"); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("Read after RemoveFolder should return ErrNotFound, got: %v", err)
+	}
+	if exists, _ := localFS.Exists(ctx, "variants/photos/cat.txt"); exists {
+		t.Fatal("Cached variant still exists after RemoveFolder")
+	}
+}
@@ -0,0 +1,86 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"cloud.google.com/go/storage"
+)
+
+// Default number of attempts of Gcs.Update.
+const DefaultUpdateAttempts = 5
+
+// Configures Gcs.Update.
+type UpdateOption func(*updateOptions)
+
+type updateOptions struct {
+	attempts int
+}
+
+// Sets how often Update reads, mutates and writes the blob before giving up
+// because of concurrent writes.
+func WithMaxAttempts(n int) UpdateOption {
+	return func(o *updateOptions) {
+		o.attempts = n
+	}
+}
+
+// Atomically replaces the blob at key with the result of mutate, which
+// receives the current data, or nil if the blob does not exist.
+//
+// The write only succeeds if the blob still has the generation that was read,
+// so no concurrent write is lost. If another write wins, the blob is read
+// again and mutate is called with the fresh data, up to DefaultUpdateAttempts
+// times in total, see WithMaxAttempts. mutate may therefore be called several
+// times and must only depend on the data it receives, without side effects.
+// An error from mutate aborts the update and is returned as is. Fails with
+// ErrPreconditionFailed when all attempts lost against concurrent writes.
+func (g *Gcs) Update(ctx context.Context, key string, mutate func(current []byte) ([]byte, error), opts ...UpdateOption) error {
+	ctx, cancel := withDefaultTimeout(ctx, g.timeout)
+	defer cancel()
+	o := updateOptions{attempts: DefaultUpdateAttempts}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var lastErr error
+	for range max(o.attempts, 1) {
+		current, generation, err := g.readGeneration(ctx, key)
+		if err != nil {
+			return err
+		}
+		data, err := mutate(current)
+		if err != nil {
+			return err
+		}
+		conds := Conditions{GenerationMatch: generation, DoesNotExist: generation == 0}
+		lastErr = g.With(conds).Write(ctx, key, data)
+		if !errors.Is(lastErr, ErrPreconditionFailed) {
+			return lastErr
+		}
+	}
+	return fmt.Errorf("updating after %d attempts: %w", o.attempts, lastErr)
+}
+
+// Reads a blob along with its generation, which is zero if it does not exist.
+func (g *Gcs) readGeneration(ctx context.Context, key string) ([]byte, int64, error) {
+	name, err := g.objectName(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	rc, err := g.bucket.Object(name).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("creating reader: %w", err)
+	}
+	defer rc.Close()
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, rc); err != nil {
+		return nil, 0, fmt.Errorf("reading: %w", err)
+	}
+	return buf.Bytes(), rc.Attrs.Generation, nil
+}
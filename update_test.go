@@ -0,0 +1,55 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestGcsBucket_Update(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGcs(t, "test-bucket")
+	gcs := fake.storage(t, "")
+	increment := func(current []byte) ([]byte, error) {
+		n, _ := strconv.Atoi(string(current))
+		return []byte(strconv.Itoa(n + 1)), nil
+	}
+
+	if err := gcs.Update(ctx, "counter", increment); err != nil {
+		t.Fatalf("Update of missing blob failed: %v", err)
+	}
+	// A write between the read and the write of the first attempt makes
+	// Update retry with the fresh data instead of losing it.
+	calls := 0
+	err := gcs.Update(ctx, "counter", func(current []byte) ([]byte, error) {
+		calls++
+		if calls == 1 {
+			fake.put("counter", []byte("10"), nil)
+		}
+		return increment(current)
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("Expected mutate to be called twice, got %d", calls)
+	}
+	if data := fake.object("counter").data; string(data) != "11" {
+		t.Fatalf("Expected 11, got %q", data)
+	}
+
+	// An update that loses every attempt gives up.
+	err = gcs.Update(ctx, "counter", func(current []byte) ([]byte, error) {
+		fake.put("counter", []byte("20"), nil)
+		return increment(current)
+	}, blob.WithMaxAttempts(2))
+	if !errors.Is(err, blob.ErrPreconditionFailed) {
+		t.Fatalf("Expected ErrPreconditionFailed after all attempts, got: %v", err)
+	}
+	if data := fake.object("counter").data; string(data) != "20" {
+		t.Fatalf("Expected the concurrent write to be kept, got %q", data)
+	}
+}
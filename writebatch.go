@@ -0,0 +1,40 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Number of concurrent writes of WriteBatch if no concurrency is given, the
+// same bound Gcs.RemoveFolder uses for deletes.
+const DefaultWriteBatchConcurrency = DefaultRemoveFolderConcurrency
+
+// Writes every blob in items to s with at most concurrency writes in flight,
+// or DefaultWriteBatchConcurrency if it is not positive, such as to store
+// thousands of small blobs without a round trip each in sequence. On the
+// first error, including cancellation of ctx, the remaining items are skipped
+// and the error is returned. The items written until then stay written.
+func WriteBatch(ctx context.Context, s Storage, items map[string][]byte, concurrency int) error {
+	if concurrency <= 0 {
+		concurrency = DefaultWriteBatchConcurrency
+	}
+	errG, gctx := errgroup.WithContext(ctx)
+	errG.SetLimit(concurrency)
+	for key, data := range items {
+		if gctx.Err() != nil {
+			break
+		}
+		errG.Go(func() error {
+			if err := s.Write(gctx, key, data); err != nil {
+				return fmt.Errorf("writing %s: %w", key, err)
+			}
+			return nil
+		})
+	}
+	if err := errG.Wait(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,42 @@
+package blob_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/acudac-com/blob-go"
+)
+
+func TestWriteBatch(t *testing.T) {
+	ctx := context.Background()
+	basePath := "test_write_batch"
+	defer os.RemoveAll(basePath)
+
+	localFS := blob.NewFsStorage(basePath)
+	items := map[string][]byte{}
+	for i := range 100 {
+		items[fmt.Sprintf("meta/%d/%d.json", i%10, i)] = []byte(fmt.Sprint(i))
+	}
+	if err := blob.WriteBatch(ctx, localFS, items, 8); err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+	for key, want := range items {
+		data, err := localFS.Read(ctx, key)
+		if err != nil {
+			t.Fatalf("Read of %s failed: %v", key, err)
+		}
+		if string(data) != string(want) {
+			t.Fatalf("Read of %s = %q, want %q", key, data, want)
+		}
+	}
+
+	// Keys that the storage rejects fail the batch.
+	policy := blob.NewKeyPolicy(localFS, blob.ValidateAlphanumericKey)
+	err := blob.WriteBatch(ctx, policy, map[string][]byte{"ok": nil, "Not OK": nil}, 0)
+	if !errors.Is(err, blob.ErrInvalidKey) {
+		t.Fatalf("Expected ErrInvalidKey, got: %v", err)
+	}
+}